@@ -0,0 +1,99 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/shininglegend/shieldbot/internal/cases"
+	"github.com/shininglegend/shieldbot/internal/config"
+	"github.com/shininglegend/shieldbot/internal/database"
+	"github.com/shininglegend/shieldbot/internal/export"
+)
+
+// runExport is the offline version of /export cases, reading the database directly:
+//
+//	bot export -guild 123 -format csv -from 2024-01-01 -to 2024-03-31 -out cases.csv -isolations isolations.csv
+func runExport(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	dbPath := flags.String("db", "", "Path to the SQLite database (default: database_path from config.yaml)")
+	guildID := flags.String("guild", "", "Only export this guild (default: all guilds)")
+	userID := flags.String("user", "", "Only export cases for this user ID")
+	action := flags.String("action", "", "Only export cases with this action")
+	from := flags.String("from", "", "First day to include, like 2024-01-01")
+	to := flags.String("to", "", "Last day to include, like 2024-03-31")
+	format := flags.String("format", export.FormatJSON, "csv or json")
+	out := flags.String("out", "", "File to write to (default: standard output)")
+	isolationsOut := flags.String("isolations", "", "With csv, file to write isolations to (default: not exported)")
+	flags.Parse(args)
+
+	filter := cases.Filter{GuildID: *guildID, UserID: *userID, Action: *action}
+	if *from != "" {
+		date, err := export.ParseDate(*from)
+		if err != nil {
+			return fmt.Errorf("invalid -from date: %w", err)
+		}
+		filter.From = date
+	}
+	if *to != "" {
+		date, err := export.ParseDate(*to)
+		if err != nil {
+			return fmt.Errorf("invalid -to date: %w", err)
+		}
+		// Include the whole last day
+		filter.To = date.AddDate(0, 0, 1)
+	}
+	if *format != export.FormatCSV && *format != export.FormatJSON {
+		return fmt.Errorf("unknown format %q, use csv or json", *format)
+	}
+
+	if *dbPath == "" {
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("no -db given and error loading config: %w", err)
+		}
+		*dbPath = cfg.DatabasePath
+	}
+	db, err := database.New(*dbPath)
+	if err != nil {
+		return fmt.Errorf("error opening database: %w", err)
+	}
+	defer db.Close()
+
+	// Brings older databases up to date, so they can be read
+	cm := cases.NewCaseManager(db)
+	err = cm.SetupTables()
+	if err != nil {
+		return fmt.Errorf("error preparing database: %w", err)
+	}
+
+	e, err := export.Load(db, cm, filter)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+
+	if *format == export.FormatJSON {
+		return e.WriteJSON(w)
+	}
+	err = e.WriteCasesCSV(w)
+	if err != nil || *isolationsOut == "" {
+		return err
+	}
+	file, err := os.Create(*isolationsOut)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return e.WriteIsolationsCSV(file)
+}
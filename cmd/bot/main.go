@@ -16,6 +16,15 @@ import (
 )
 
 func main() {
+	// Offline tools, which don't connect to Discord
+	if len(os.Args) > 1 && os.Args[1] == "export" {
+		err := runExport(os.Args[2:])
+		if err != nil {
+			log.Fatalf("Error exporting: %v", err)
+		}
+		return
+	}
+
 	var bot *bot.Bot
 	defer func() {
 		if r := recover(); r != nil {
@@ -42,7 +51,7 @@ func main() {
 	}
 	defer db.Close()
 
-	bot, err = bot.New(cfg.Token, db)
+	bot, err = bot.New(cfg, db)
 	if err != nil {
 		log.Fatalf("Error creating bot: %v", err)
 	}
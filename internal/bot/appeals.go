@@ -0,0 +1,347 @@
+package bot
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/shininglegend/shieldbot/pkg/auth"
+	"github.com/shininglegend/shieldbot/pkg/utils"
+)
+
+// Custom ID of the text inputs in the appeal modals
+const (
+	inputAppeal = "appeal"
+	inputReply  = "reply"
+)
+
+// appealButtons returns a button to appeal for each guild the user is isolated in,
+// along with notes for guilds where they can't appeal right now.
+func (b *Bot) appealButtons(s *discordgo.Session, userID string) ([]discordgo.MessageComponent, utils.Messages) {
+	messages := utils.Messages{}
+	rows, err := b.db.Query("SELECT guild_id FROM user_roles WHERE user_id = ?", userID)
+	if err != nil {
+		log.Printf("Error fetching isolations: %v", err)
+		return nil, messages
+	}
+	var guildIDs []string
+	for rows.Next() {
+		var guildID string
+		if err := rows.Scan(&guildID); err != nil {
+			log.Printf("Error scanning isolation: %v", err)
+			continue
+		}
+		guildIDs = append(guildIDs, guildID)
+	}
+	rows.Close()
+
+	var buttons []discordgo.MessageComponent
+	for _, guildID := range guildIDs {
+		name := guildName(s, guildID)
+		if _, errEmbed := b.checkAppealable(guildID, userID); errEmbed != nil {
+			messages.AddMessage(fmt.Sprintf("**%v**: %v", name, errEmbed.Description))
+			continue
+		}
+		// Discord allows at most 5 buttons per row
+		if len(buttons) == 5 {
+			messages.AddMessage(fmt.Sprintf("**%v**: Too many appeals at once, appeal elsewhere first.", name))
+			continue
+		}
+		buttons = append(buttons, discordgo.Button{
+			Label:    fmt.Sprintf("Appeal in %v", truncate(name, 60)),
+			Style:    discordgo.PrimaryButton,
+			CustomID: makeCustomID(compAppealOpen, guildID),
+		})
+	}
+	return buttons, messages
+}
+
+// checkAppealable ensures the user can currently appeal in a guild, and returns the appeals channel
+func (b *Bot) checkAppealable(guildID, userID string) (string, *discordgo.MessageEmbed) {
+	var exists int
+	err := b.db.QueryRow("SELECT 1 FROM user_roles WHERE user_id = ? AND guild_id = ?", userID, guildID).Scan(&exists)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("Error fetching isolation: %v", err)
+		}
+		return "", utils.CreateNotAllowedEmbed("Unable to appeal", "You are not isolated in this server.")
+	}
+
+	err = b.db.QueryRow("SELECT 1 FROM appeals WHERE user_id = ? AND guild_id = ?", userID, guildID).Scan(&exists)
+	if err == nil {
+		return "", utils.CreateNotAllowedEmbed("Unable to appeal", "You already have an appeal waiting for review.")
+	} else if err != sql.ErrNoRows {
+		log.Printf("Error fetching appeal: %v", err)
+	}
+
+	channelID, err := b.pm.GetAppealsChannelID(guildID)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("Error fetching appeals channel: %v", err)
+		}
+		return "", utils.CreateNotAllowedEmbed("Unable to appeal", "This server doesn't take appeals through the bot. Please contact the moderators directly.")
+	}
+	return channelID, nil
+}
+
+// handleAppealOpen opens the appeal form for a user who pressed the appeal button
+func (b *Bot) handleAppealOpen(s *discordgo.Session, i *discordgo.InteractionCreate, args []string) {
+	guildID := args[0]
+	if _, errEmbed := b.checkAppealable(guildID, utils.SafeUser(i.Interaction).ID); errEmbed != nil {
+		respondEphemeral(s, i, errEmbed)
+		return
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID: makeCustomID(compAppealSubmit, guildID),
+			Title:    "Appeal your isolation",
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					discordgo.TextInput{
+						CustomID:  inputAppeal,
+						Label:     "Why should you be restored?",
+						Style:     discordgo.TextInputParagraph,
+						Required:  true,
+						MaxLength: 1500,
+					},
+				}},
+			},
+		},
+	})
+	if err != nil {
+		log.Printf("Error opening appeal modal: %v", err)
+	}
+}
+
+// handleAppealSubmit posts a submitted appeal to the guild's appeals channel for review
+func (b *Bot) handleAppealSubmit(s *discordgo.Session, i *discordgo.InteractionCreate, args []string) {
+	guildID := args[0]
+	user := utils.SafeUser(i.Interaction)
+	channelID, errEmbed := b.checkAppealable(guildID, user.ID)
+	if errEmbed != nil {
+		respondEphemeral(s, i, errEmbed)
+		return
+	}
+
+	msg, err := s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Embed: &discordgo.MessageEmbed{
+			Title:       "Isolation Appeal",
+			Description: modalValue(i, inputAppeal),
+			Color:       0x0000FF, // Blue
+			Timestamp:   time.Now().Format(time.RFC3339),
+			Thumbnail:   &discordgo.MessageEmbedThumbnail{URL: user.AvatarURL("")},
+			Fields: []*discordgo.MessageEmbedField{
+				{
+					Name:   "User",
+					Value:  fmt.Sprintf("%v `%v`", user.Mention(), user.ID),
+					Inline: true,
+				},
+			},
+		},
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.Button{Label: "Accept", Style: discordgo.SuccessButton, CustomID: makeCustomID(compAppealAccept, guildID, user.ID)},
+				discordgo.Button{Label: "Deny", Style: discordgo.DangerButton, CustomID: makeCustomID(compAppealDeny, guildID, user.ID)},
+				discordgo.Button{Label: "Reply", Style: discordgo.SecondaryButton, CustomID: makeCustomID(compAppealReply, guildID, user.ID)},
+			}},
+		},
+	})
+	if err != nil {
+		log.Printf("Error posting appeal: %v", err)
+		respondEphemeral(s, i, utils.CreateNotAllowedEmbed("Unable to appeal", "Your appeal couldn't be delivered. Please contact the moderators directly."))
+		return
+	}
+
+	_, err = b.db.Exec("INSERT INTO appeals (user_id, guild_id, channel_id, message_id, created_at) VALUES (?, ?, ?, ?, ?)",
+		user.ID, guildID, channelID, msg.ID, time.Now().Unix())
+	if err != nil {
+		log.Printf("Error saving appeal: %v", err)
+	}
+
+	respondEphemeral(s, i, utils.CreateEmbed("Appeal sent", fmt.Sprintf("Your appeal has been sent to the moderators of %v. You'll get a message here once they've reviewed it.", guildName(s, guildID))))
+}
+
+// handleAppealAccept restores the user and closes the appeal
+func (b *Bot) handleAppealAccept(s *discordgo.Session, i *discordgo.InteractionCreate, args []string) {
+	if e := auth.QuickAuthManageRolesOrOverrideFor(b.pm, s, i, cmdRestore); e != nil {
+		respondEphemeral(s, i, e)
+		return
+	}
+	guildID, userID := args[0], args[1]
+
+	// Restoring takes a few requests, so acknowledge first
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredMessageUpdate,
+	})
+	if err != nil {
+		log.Printf("Error acknowledging interaction: %v", err)
+		return
+	}
+
+	user, err := s.User(userID)
+	if err != nil {
+		followupEphemeral(s, i, utils.CreateErrorEmbed(s, i, "Failed to fetch user", err))
+		return
+	}
+	messages, errEmbed := b.restoreUser(s, i, user, "Appeal accepted")
+	if errEmbed != nil {
+		followupEphemeral(s, i, errEmbed)
+		return
+	}
+
+	dmErr := b.resolveAppeal(s, i, guildID, user, "Accepted", 0x00FF00, "Your appeal was accepted and your roles have been restored.")
+	if dmErr != nil {
+		messages.AddMessage(fmt.Sprintf("Could not DM %v about the outcome.", user.Mention()))
+	}
+	followupEphemeral(s, i, utils.CreateEmbed(fmt.Sprintf("User %s (`%v`) has been restored.", user.Username, user.ID), messages.GetMessages("")))
+}
+
+// handleAppealDeny closes the appeal without restoring the user
+func (b *Bot) handleAppealDeny(s *discordgo.Session, i *discordgo.InteractionCreate, args []string) {
+	if e := auth.QuickAuthManageRolesOrOverrideFor(b.pm, s, i, cmdIsolate); e != nil {
+		respondEphemeral(s, i, e)
+		return
+	}
+	guildID, userID := args[0], args[1]
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredMessageUpdate,
+	})
+	if err != nil {
+		log.Printf("Error acknowledging interaction: %v", err)
+		return
+	}
+
+	user, err := s.User(userID)
+	if err != nil {
+		followupEphemeral(s, i, utils.CreateErrorEmbed(s, i, "Failed to fetch user", err))
+		return
+	}
+	dmErr := b.resolveAppeal(s, i, guildID, user, "Denied", 0xFF0000, "Your appeal was denied.")
+	if dmErr != nil {
+		followupEphemeral(s, i, utils.CreateNotAllowedEmbed("Appeal denied", fmt.Sprintf("Could not DM %v about the outcome.", user.Mention())))
+	}
+}
+
+// handleAppealReply opens a form for staff to reply to the user, and sends the reply once submitted
+func (b *Bot) handleAppealReply(s *discordgo.Session, i *discordgo.InteractionCreate, args []string) {
+	if e := auth.QuickAuthManageRolesOrOverrideFor(b.pm, s, i, cmdIsolate); e != nil {
+		respondEphemeral(s, i, e)
+		return
+	}
+	guildID, userID := args[0], args[1]
+
+	// The button opens the form, the form submission sends the reply
+	if i.Type == discordgo.InteractionMessageComponent {
+		err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseModal,
+			Data: &discordgo.InteractionResponseData{
+				CustomID: makeCustomID(compAppealReply, guildID, userID),
+				Title:    "Reply to appeal",
+				Components: []discordgo.MessageComponent{
+					discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+						discordgo.TextInput{
+							CustomID:  inputReply,
+							Label:     "Message to the user",
+							Style:     discordgo.TextInputParagraph,
+							Required:  true,
+							MaxLength: 1000,
+						},
+					}},
+				},
+			},
+		})
+		if err != nil {
+			log.Printf("Error opening reply modal: %v", err)
+		}
+		return
+	}
+
+	reply := modalValue(i, inputReply)
+	err := utils.SendDM(s, userID, &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("The moderators of %v replied to your appeal", guildName(s, guildID)),
+		Description: reply,
+		Color:       0x0000FF, // Blue
+		Timestamp:   time.Now().Format(time.RFC3339),
+	})
+	if err != nil {
+		log.Printf("Error sending appeal reply: %v", err)
+		respondEphemeral(s, i, utils.CreateNotAllowedEmbed("Reply not sent", "Could not DM the user, their DMs may be closed."))
+		return
+	}
+
+	// Keep a record of the reply on the appeal itself
+	embed := i.Message.Embeds[0]
+	if len(embed.Fields) < 25 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  fmt.Sprintf("Reply from %v", i.Member.User.Username),
+			Value: truncate(reply, 1024),
+		})
+	}
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: i.Message.Components,
+		},
+	})
+	if err != nil {
+		log.Printf("Error updating appeal: %v", err)
+	}
+}
+
+// resolveAppeal marks the appeal message with the outcome, removes its buttons and lets the user know.
+// The interaction must already be acknowledged. Returns an error only if the user couldn't be DMed.
+func (b *Bot) resolveAppeal(s *discordgo.Session, i *discordgo.InteractionCreate, guildID string, user *discordgo.User, outcome string, color int, dm string) error {
+	_, err := b.db.Exec("DELETE FROM appeals WHERE user_id = ? AND guild_id = ?", user.ID, guildID)
+	if err != nil {
+		log.Printf("Error deleting appeal: %v", err)
+	}
+
+	embed := i.Message.Embeds[0]
+	embed.Color = color
+	embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+		Name:   outcome,
+		Value:  fmt.Sprintf("By %v", i.Member.User.Mention()),
+		Inline: true,
+	})
+	_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Embeds:     &[]*discordgo.MessageEmbed{embed},
+		Components: &[]discordgo.MessageComponent{},
+	})
+	if err != nil {
+		log.Printf("Error updating appeal: %v", err)
+	}
+
+	return utils.SendDM(s, user.ID, &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("Appeal %v", outcome),
+		Description: fmt.Sprintf("%v\nServer: %v", dm, guildName(s, guildID)),
+		Color:       color,
+		Timestamp:   time.Now().Format(time.RFC3339),
+	})
+}
+
+// guildName returns the name of a guild, or its ID if it can't be found
+func guildName(s *discordgo.Session, guildID string) string {
+	guild, err := s.State.Guild(guildID)
+	if err != nil {
+		guild, err = s.Guild(guildID)
+		if err != nil {
+			return guildID
+		}
+	}
+	return guild.Name
+}
+
+// truncate shortens a string to at most max characters
+func truncate(str string, max int) string {
+	runes := []rune(str)
+	if len(runes) <= max {
+		return str
+	}
+	return string(runes[:max-1]) + "…"
+}
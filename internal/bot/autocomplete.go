@@ -0,0 +1,85 @@
+package bot
+
+import (
+	"log"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/shininglegend/shieldbot/internal/commands"
+)
+
+// Discord shows at most this many autocomplete choices
+const maxAutocompleteChoices = 25
+
+func (b *Bot) handleAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionApplicationCommandAutocomplete {
+		return
+	}
+	data := i.ApplicationCommandData()
+	path, focused := focusedOption(data.Options)
+	if focused == nil {
+		return
+	}
+
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	switch focused.Name {
+	case "action":
+		// Only custom actions can be removed
+		customOnly := data.Name == cmdConfigType && len(path) > 0 && path[0] == commands.ActionsGroupName
+		choices = b.actionAutocomplete(i.GuildID, focused.StringValue(), customOnly)
+	case "reason":
+		choices = b.reasonAutocomplete(i.GuildID, focused.StringValue())
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+		Data: &discordgo.InteractionResponseData{
+			Choices: choices,
+		},
+	})
+	if err != nil {
+		log.Printf("Error responding to autocomplete: %v", err)
+	}
+}
+
+// focusedOption finds the option being typed in, along with the names of the subcommands it's under
+func focusedOption(options []*discordgo.ApplicationCommandInteractionDataOption) ([]string, *discordgo.ApplicationCommandInteractionDataOption) {
+	for _, opt := range options {
+		if opt.Focused {
+			return nil, opt
+		}
+		if opt.Type == discordgo.ApplicationCommandOptionSubCommand || opt.Type == discordgo.ApplicationCommandOptionSubCommandGroup {
+			if path, focused := focusedOption(opt.Options); focused != nil {
+				return append([]string{opt.Name}, path...), focused
+			}
+		}
+	}
+	return nil, nil
+}
+
+// actionAutocomplete suggests the built-in and custom actions matching what was typed
+func (b *Bot) actionAutocomplete(guildID, typed string, customOnly bool) []*discordgo.ApplicationCommandOptionChoice {
+	var all []*discordgo.ApplicationCommandOptionChoice
+	if !customOnly {
+		all = actionChoices()
+	}
+	custom, err := b.cm.GetActionTypes(guildID)
+	if err != nil {
+		log.Printf("Error fetching action types: %v", err)
+	}
+	for _, a := range custom {
+		all = append(all, &discordgo.ApplicationCommandOptionChoice{Name: a.Label, Value: a.Name})
+	}
+
+	typed = strings.ToLower(typed)
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, 0, maxAutocompleteChoices)
+	for _, choice := range all {
+		if len(choices) == maxAutocompleteChoices {
+			break
+		}
+		if strings.Contains(strings.ToLower(choice.Name), typed) || strings.Contains(choice.Value.(string), typed) {
+			choices = append(choices, choice)
+		}
+	}
+	return choices
+}
@@ -5,7 +5,11 @@ import (
 	"database/sql"
 
 	"github.com/bwmarrin/discordgo"
+	"github.com/shininglegend/shieldbot/internal/cases"
 	"github.com/shininglegend/shieldbot/internal/commands"
+	"github.com/shininglegend/shieldbot/internal/config"
+	"github.com/shininglegend/shieldbot/internal/logging"
+	"github.com/shininglegend/shieldbot/internal/msgcache"
 	"github.com/shininglegend/shieldbot/internal/permissions"
 	"github.com/shininglegend/shieldbot/pkg/utils"
 )
@@ -15,17 +19,25 @@ type Bot struct {
 	db                 *sql.DB
 	pm                 *permissions.PermissionManager
 	pc                 *commands.PermissionCommands
+	cm                 *cases.CaseManager
+	logger             *logging.Logger // Sends every log message to the channel of its category
+	messages           *msgcache.Cache // Recent messages, to log edits and deletes
+	tracker            *actionTracker  // Bans, kicks and timeouts logged recently
+	members            *memberTracker  // Roles and join dates, for the leave log
+	pendingLogs        *pendingLogs    // /elog previews waiting to be confirmed
+	stop               chan struct{}   // Closed to stop background tasks
 	registeredCommands map[string]*discordgo.ApplicationCommand
 }
 
-func (*Bot) New(token string, db *sql.DB) (*Bot, error) {
-	session, err := discordgo.New("Bot " + token)
+func (*Bot) New(cfg *config.Config, db *sql.DB) (*Bot, error) {
+	session, err := discordgo.New("Bot " + cfg.Token)
 	if err != nil {
 		return nil, err
 	}
 
 	// Request intents
-	session.Identify.Intents = discordgo.IntentsAllWithoutPrivileged | discordgo.IntentsGuildMembers
+	// Message content is needed to log edits and deletes
+	session.Identify.Intents = discordgo.IntentsAllWithoutPrivileged | discordgo.IntentsGuildMembers | discordgo.IntentMessageContent
 
 	pm := permissions.NewPermissionManager(db)
 	err = pm.SetupTables()
@@ -33,16 +45,49 @@ func (*Bot) New(token string, db *sql.DB) (*Bot, error) {
 		return nil, err
 	}
 
-	pc := commands.NewPermissionCommands(pm)
+	cm := cases.NewCaseManager(db)
+	err = cm.SetupTables()
+	if err != nil {
+		return nil, err
+	}
+
+	logger := logging.New(pm)
+	pc := commands.NewPermissionCommands(pm, cm, logger)
+
+	// Only saved to the database if asked to
+	cacheDB := db
+	if !cfg.MessageCachePersist {
+		cacheDB = nil
+	}
+	messages := msgcache.NewCache(cacheDB, cfg.MessageCacheSize)
+	err = messages.SetupTables()
+	if err != nil {
+		return nil, err
+	}
 
 	bot := &Bot{
-		Session: session,
-		db:      db,
-		pm:      pm,
-		pc:      pc,
+		Session:     session,
+		db:          db,
+		pm:          pm,
+		pc:          pc,
+		cm:          cm,
+		logger:      logger,
+		messages:    messages,
+		tracker:     newActionTracker(),
+		members:     newMemberTracker(),
+		pendingLogs: newPendingLogs(),
 	}
 
 	session.AddHandler(bot.handleCommands)
+	session.AddHandler(bot.handleComponents)
+	session.AddHandler(bot.handleAutocomplete)
+
+	// Guilds arrive as soon as the session opens, so these can't wait for registerEvents
+	session.AddHandler(bot.trackGuild)
+	session.AddHandler(bot.trackMemberChunk)
+
+	// Errors also go to the guild's own error log
+	utils.OnError = bot.logError
 
 	return bot, nil
 }
@@ -65,11 +110,18 @@ func (b *Bot) Start() error {
 	// Add message handlers
 	b.AddMessageHandlers()
 
+	// Lift temporary bans and isolations as they expire
+	b.stop = make(chan struct{})
+	go b.runExpiryScheduler(b.stop)
+
 	utils.SendToDevChannelDMs(b.Session, "Bot has started", 0)
 	return nil
 }
 
 func (b *Bot) Stop() {
+	if b.stop != nil {
+		close(b.stop)
+	}
 	b.Session.Close()
 }
 
@@ -80,4 +132,20 @@ func (b *Bot) AddMessageHandlers() {
 func (b *Bot) registerEvents() {
 	b.Session.AddHandler(b.HandleJoin)
 	b.Session.AddHandler(b.HandleLeave)
+	b.Session.AddHandler(b.trackMemberUpdate)
+
+	// Moderation done through Discord rather than the bot
+	b.Session.AddHandler(b.handleAuditLogEvent)
+	b.Session.AddHandler(b.handleBanAdd)
+	b.Session.AddHandler(b.handleBanRemove)
+	b.Session.AddHandler(b.handleMemberUpdate)
+
+	// AutoMod hits
+	b.Session.AddHandler(b.handleAutoModAction)
+
+	// Message edits and deletes
+	b.Session.AddHandler(b.cacheMessage)
+	b.Session.AddHandler(b.handleMessageEdit)
+	b.Session.AddHandler(b.handleMessageDelete)
+	b.Session.AddHandler(b.handleMessageDeleteBulk)
 }
@@ -0,0 +1,108 @@
+package bot
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/shininglegend/shieldbot/internal/cases"
+	"github.com/shininglegend/shieldbot/pkg/auth"
+	"github.com/shininglegend/shieldbot/pkg/utils"
+)
+
+const (
+	// Subcommands of /case
+	caseView   = "view"
+	caseReason = "reason"
+	caseDelete = "delete"
+)
+
+func (b *Bot) handleCase(s *discordgo.Session, i *discordgo.InteractionCreate) *discordgo.MessageEmbed {
+	subcommand := i.ApplicationCommandData().Options[0]
+	options := subcommand.Options
+
+	// Deleting records needs more than viewing or editing them
+	if subcommand.Name == caseDelete {
+		if e := auth.QuickAuthAdminOrOverride(b.pm, s, i); e != nil {
+			return e
+		}
+	} else if e := auth.QuickAuthManageMessagesOrOverride(b.pm, s, i); e != nil {
+		return e
+	}
+
+	c, err := b.cm.GetCase(i.GuildID, int(options[0].IntValue()))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return utils.CreateNotAllowedEmbed("Case not found", fmt.Sprintf("There is no case #%v in this server.", options[0].IntValue()))
+		}
+		return utils.CreateErrorEmbed(s, i, "Failed to fetch case", err)
+	}
+
+	// Needed whenever the case is shown again
+	c.Attachments, err = b.cm.GetAttachments(c.GuildID, c.Number)
+	if err != nil {
+		log.Printf("Error fetching attachments of case %v: %v", c.Number, err)
+	}
+
+	switch subcommand.Name {
+	case caseView:
+		embed := b.caseEmbed(c)
+		if c.LogMessageID != "" {
+			embed.URL = messageLink(c.GuildID, c.LogChannelID, c.LogMessageID)
+		}
+		return embed
+	case caseReason:
+		return b.handleCaseReason(s, i, c, options[1].StringValue())
+	case caseDelete:
+		return b.handleCaseDelete(s, i, c)
+	default:
+		return utils.CreateNotAllowedEmbed("Unknown subcommand to case", fmt.Sprintf("Unknown subcommand: %v", subcommand.Name))
+	}
+}
+
+func (b *Bot) handleCaseReason(s *discordgo.Session, i *discordgo.InteractionCreate, c *cases.Case, reason string) *discordgo.MessageEmbed {
+	err := b.cm.UpdateReason(c.GuildID, c.Number, reason)
+	if err != nil {
+		return utils.CreateErrorEmbed(s, i, "Failed to update reason", err)
+	}
+	c.Reason = reason
+
+	// Keep the log message in sync
+	messages := utils.Messages{}
+	if c.LogMessageID != "" {
+		_, err = s.ChannelMessageEditEmbed(c.LogChannelID, c.LogMessageID, b.caseEmbed(c))
+		if err != nil {
+			log.Printf("Error editing log message: %v", err)
+			messages.AddMessage("Could not update the log message, it may have been deleted.")
+		}
+	}
+	return utils.CreateEmbed(fmt.Sprintf("Case #%v updated", c.Number), messages.GetMessages(fmt.Sprintf("New reason: %v", reason)))
+}
+
+func (b *Bot) handleCaseDelete(s *discordgo.Session, i *discordgo.InteractionCreate, c *cases.Case) *discordgo.MessageEmbed {
+	err := b.cm.DeleteCase(c.GuildID, c.Number)
+	if err != nil {
+		return utils.CreateErrorEmbed(s, i, "Failed to delete case", err)
+	}
+
+	// Mark the log message rather than removing it, so the channel still shows what happened
+	messages := utils.Messages{}
+	if c.LogMessageID != "" {
+		embed := b.caseEmbed(c)
+		embed.Title = fmt.Sprintf("%v (deleted)", embed.Title)
+		embed.Color = 0x808080 // Grey
+		embed.Footer.Text = fmt.Sprintf("Case deleted by %v", i.Member.User.Username)
+		_, err = s.ChannelMessageEditEmbed(c.LogChannelID, c.LogMessageID, embed)
+		if err != nil {
+			log.Printf("Error editing log message: %v", err)
+			messages.AddMessage("Could not update the log message, it may have been deleted.")
+		}
+	}
+	return utils.CreateEmbed(fmt.Sprintf("Case #%v deleted", c.Number), messages.GetMessages(""))
+}
+
+// messageLink returns a jump link to a message
+func messageLink(guildID, channelID, messageID string) string {
+	return fmt.Sprintf("https://discord.com/channels/%v/%v/%v", guildID, channelID, messageID)
+}
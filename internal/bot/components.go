@@ -0,0 +1,130 @@
+// This file routes message component (button, select menu) and modal interactions
+package bot
+
+import (
+	"log"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// Custom ID prefixes, arguments follow separated by customIDSeparator.
+	compAppealOpen         = "appeal_open"          // guildID
+	compAppealSubmit       = "appeal_submit"        // guildID
+	compAppealAccept       = "appeal_accept"        // guildID, userID
+	compAppealDeny         = "appeal_deny"          // guildID, userID
+	compAppealReply        = "appeal_reply"         // guildID, userID
+	compModmailOpen        = "modmail_open"         // select menu, value is the guildID
+	compHistoryPage        = "history_page"         // userID, page
+	compLogForm            = "log_form"             // userID, action, execute (0 or 1), duration
+	compElogConfirm        = "elog_confirm"         // ID of the pending log
+	compElogCancel         = "elog_cancel"          // ID of the pending log
+	compWhoisIsolate       = "whois_isolate"        // userID
+	compWhoisIsolateSubmit = "whois_isolate_submit" // userID
+	compWhoisLog           = "whois_log"            // userID
+	compWhoisLogAction     = "whois_log_action"     // select menu, userID
+	compWhoisHistory       = "whois_history"        // userID
+
+	customIDSeparator = ":"
+)
+
+// makeCustomID joins a prefix and its arguments into a component custom ID
+func makeCustomID(prefix string, args ...string) string {
+	return strings.Join(append([]string{prefix}, args...), customIDSeparator)
+}
+
+// parseCustomID splits a component custom ID into its prefix and arguments
+func parseCustomID(customID string) (string, []string) {
+	parts := strings.Split(customID, customIDSeparator)
+	return parts[0], parts[1:]
+}
+
+func (b *Bot) handleComponents(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	var customID string
+	switch i.Type {
+	case discordgo.InteractionMessageComponent:
+		customID = i.MessageComponentData().CustomID
+	case discordgo.InteractionModalSubmit:
+		customID = i.ModalSubmitData().CustomID
+	default:
+		return
+	}
+
+	prefix, args := parseCustomID(customID)
+	switch prefix {
+	case compAppealOpen:
+		b.handleAppealOpen(s, i, args)
+	case compAppealSubmit:
+		b.handleAppealSubmit(s, i, args)
+	case compAppealAccept:
+		b.handleAppealAccept(s, i, args)
+	case compAppealDeny:
+		b.handleAppealDeny(s, i, args)
+	case compAppealReply:
+		b.handleAppealReply(s, i, args)
+	case compModmailOpen:
+		b.handleModmailOpen(s, i)
+	case compHistoryPage:
+		b.handleHistoryPage(s, i, args)
+	case compLogForm:
+		b.handleLogFormSubmit(s, i, args)
+	case compElogConfirm:
+		b.handleElogConfirm(s, i, args)
+	case compElogCancel:
+		b.handleElogCancel(s, i, args)
+	case compWhoisIsolate:
+		b.handleWhoisIsolate(s, i, args)
+	case compWhoisIsolateSubmit:
+		b.handleWhoisIsolateSubmit(s, i, args)
+	case compWhoisLog:
+		b.handleWhoisLog(s, i, args)
+	case compWhoisLogAction:
+		b.handleWhoisLogAction(s, i, args)
+	case compWhoisHistory:
+		b.handleWhoisHistory(s, i, args)
+	default:
+		log.Printf("Unknown component: %v", customID)
+	}
+}
+
+// respondEphemeral answers a component interaction with a message only the clicker can see
+func respondEphemeral(s *discordgo.Session, i *discordgo.InteractionCreate, embed *discordgo.MessageEmbed) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+			Flags:  discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		log.Printf("Error responding to interaction: %v", err)
+	}
+}
+
+// followupEphemeral sends a message only the clicker can see, after the interaction was already acknowledged
+func followupEphemeral(s *discordgo.Session, i *discordgo.InteractionCreate, embed *discordgo.MessageEmbed) {
+	_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+		Embeds: []*discordgo.MessageEmbed{embed},
+		Flags:  discordgo.MessageFlagsEphemeral,
+	})
+	if err != nil {
+		log.Printf("Error creating follow-up message: %v", err)
+	}
+}
+
+// modalValue returns the value of a text input in a submitted modal
+func modalValue(i *discordgo.InteractionCreate, customID string) string {
+	for _, row := range i.ModalSubmitData().Components {
+		actionsRow, ok := row.(*discordgo.ActionsRow)
+		if !ok {
+			continue
+		}
+		for _, component := range actionsRow.Components {
+			if input, ok := component.(*discordgo.TextInput); ok && input.CustomID == customID {
+				return input.Value
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,238 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/shininglegend/shieldbot/pkg/auth"
+	"github.com/shininglegend/shieldbot/pkg/utils"
+)
+
+const (
+	// Most users one /elog can log, one preview field each
+	maxBulkLog = 25
+	// How long a preview can be confirmed
+	pendingLogExpiry = 10 * time.Minute
+)
+
+// Matches user IDs on their own or in mentions
+var userIDPattern = regexp.MustCompile(`^(?:<@!?)?(\d{15,21})>?$`)
+
+// pendingLog is an /elog waiting for the moderator to confirm the preview.
+// The preview is ephemeral, so only that moderator can confirm it.
+type pendingLog struct {
+	users    []*discordgo.User
+	action   string
+	reason   string
+	evidence []*discordgo.MessageAttachment
+	created  time.Time
+}
+
+// pendingLogs holds /elog previews by the ID of the interaction that created them
+type pendingLogs struct {
+	mu   sync.Mutex
+	logs map[string]*pendingLog
+}
+
+func newPendingLogs() *pendingLogs {
+	return &pendingLogs{logs: make(map[string]*pendingLog)}
+}
+
+func (p *pendingLogs) add(id string, l *pendingLog) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for key, old := range p.logs {
+		if time.Since(old.created) > pendingLogExpiry {
+			delete(p.logs, key)
+		}
+	}
+	p.logs[id] = l
+}
+
+// take removes and returns a pending log, or nil if it expired or was already handled
+func (p *pendingLogs) take(id string) *pendingLog {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	l, ok := p.logs[id]
+	delete(p.logs, id)
+	if !ok || time.Since(l.created) > pendingLogExpiry {
+		return nil
+	}
+	return l
+}
+
+// handleLoggingExternal resolves every user given to /elog and previews them, the action is logged once confirmed
+func (b *Bot) handleLoggingExternal(s *discordgo.Session, i *discordgo.InteractionCreate) (*discordgo.MessageEmbed, []discordgo.MessageComponent) {
+	if e := auth.QuickAuthManageMessagesOrOverride(b.pm, s, i); e != nil {
+		return e, nil
+	}
+	options := i.ApplicationCommandData().Options
+	action := options[1].StringValue()
+	if _, _, ok := b.actionType(i.GuildID, action); !ok {
+		return utils.CreateNotAllowedEmbed("Unknown action", fmt.Sprintf("There is no action `%v`. Pick one from the list, or add it with /config actions add.", action)), nil
+	}
+
+	// Accept IDs, mentions and usernames, separated by spaces or commas
+	input := strings.FieldsFunc(options[0].StringValue(), func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\n'
+	})
+	messages := utils.Messages{}
+	var users []*discordgo.User
+	seen := make(map[string]bool)
+	for _, entry := range input {
+		user, problem := resolveElogUser(s, i.GuildID, entry)
+		if user == nil {
+			messages.AddMessage(problem)
+			continue
+		}
+		if seen[user.ID] {
+			continue
+		}
+		seen[user.ID] = true
+		users = append(users, user)
+	}
+	if len(users) == 0 {
+		return utils.CreateNotAllowedEmbed("No users found", messages.GetMessages("Give one or more user IDs, mentions or usernames, separated by spaces or commas.")), nil
+	}
+	if len(users) > maxBulkLog {
+		return utils.CreateNotAllowedEmbed("Too many users", fmt.Sprintf("At most %v users can be logged at once, %v were given.", maxBulkLog, len(users))), nil
+	}
+
+	b.pendingLogs.add(i.ID, &pendingLog{
+		users:    users,
+		action:   action,
+		reason:   optionalReason(options),
+		evidence: evidenceAttachments(i),
+		created:  time.Now(),
+	})
+
+	label, color, _ := b.actionType(i.GuildID, action)
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("Log %v for %v user(s)?", label, len(users)),
+		Description: messages.GetMessages(fmt.Sprintf("**Reason:** %v", reasonOrDefault(optionalReason(options)))),
+		Color:       color,
+		Footer:      &discordgo.MessageEmbedFooter{Text: "Check these are the right accounts, then confirm."},
+	}
+	if len(users) == 1 {
+		embed.Thumbnail = &discordgo.MessageEmbedThumbnail{URL: users[0].AvatarURL("")}
+	}
+	for _, user := range users {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   user.Username,
+			Value:  fmt.Sprintf("`%v`\n[Avatar](%v)", user.ID, user.AvatarURL("")),
+			Inline: true,
+		})
+	}
+	return embed, []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{Label: "Confirm", Style: discordgo.DangerButton, CustomID: makeCustomID(compElogConfirm, i.ID)},
+			discordgo.Button{Label: "Cancel", Style: discordgo.SecondaryButton, CustomID: makeCustomID(compElogCancel, i.ID)},
+		}},
+	}
+}
+
+// resolveElogUser finds the user an /elog entry names, by ID, mention or the username of a member.
+// Returns why it couldn't if there's no user.
+func resolveElogUser(s *discordgo.Session, guildID, entry string) (*discordgo.User, string) {
+	if match := userIDPattern.FindStringSubmatch(entry); match != nil {
+		user, err := s.User(match[1])
+		if err != nil {
+			return nil, fmt.Sprintf("`%v` is not a known user.", match[1])
+		}
+		return user, ""
+	}
+
+	// Anyone else has to be found among the members by name
+	name := strings.TrimPrefix(entry, "@")
+	members, err := s.GuildMembersSearch(guildID, name, maxBulkLog)
+	if err != nil {
+		log.Printf("Error searching members: %v", err)
+		return nil, fmt.Sprintf("Could not search for `%v`.", truncate(entry, 40))
+	}
+	var found []*discordgo.User
+	for _, m := range members {
+		if strings.EqualFold(m.User.Username, name) {
+			return m.User, ""
+		}
+		found = append(found, m.User)
+	}
+	switch len(found) {
+	case 0:
+		return nil, fmt.Sprintf("`%v` is not a user ID, mention or member's username.", truncate(entry, 40))
+	case 1:
+		return found[0], ""
+	}
+	return nil, fmt.Sprintf("`%v` matches %v members, use their username or ID.", truncate(entry, 40), len(found))
+}
+
+// handleElogConfirm logs the previewed action for every user
+func (b *Bot) handleElogConfirm(s *discordgo.Session, i *discordgo.InteractionCreate, args []string) {
+	if e := auth.QuickAuthManageMessagesOrOverrideFor(b.pm, s, i, cmdLoggingExt); e != nil {
+		respondEphemeral(s, i, e)
+		return
+	}
+	pending := b.pendingLogs.take(args[0])
+	if pending == nil {
+		b.closeElogPreview(s, i, utils.CreateNotAllowedEmbed("Preview expired", "Nothing was logged. Run /elog again."))
+		return
+	}
+
+	// Logging takes a few requests per user, so acknowledge first
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredMessageUpdate,
+	})
+	if err != nil {
+		log.Printf("Error acknowledging interaction: %v", err)
+		return
+	}
+
+	messages := utils.Messages{}
+	logged := 0
+	for _, user := range pending.users {
+		c, errEmbed := b.logAction(s, i, user, pending.action, pending.reason, &caseEvidence{files: pending.evidence})
+		if errEmbed != nil {
+			messages.AddMessage(fmt.Sprintf("%v `%v`: **not logged**, %v", user.Username, user.ID, errEmbed.Description))
+			continue
+		}
+		logged++
+		messages.AddMessage(fmt.Sprintf("%v `%v`: case #%v", user.Username, user.ID, c.Number))
+		// Same as /log, the new points may cross a threshold
+		messages = append(messages, b.escalate(s, i, user, c)...)
+	}
+
+	embed := utils.CreateEmbed("Logged action", messages.GetMessages(fmt.Sprintf("Logged %v for %v of %v user(s).", pending.action, logged, len(pending.users))))
+	if logged < len(pending.users) {
+		embed.Color = 0xFFFF00 // Yellow
+	}
+	_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Embeds:     &[]*discordgo.MessageEmbed{embed},
+		Components: &[]discordgo.MessageComponent{},
+	})
+	if err != nil {
+		log.Printf("Error editing interaction response: %v", err)
+	}
+}
+
+func (b *Bot) handleElogCancel(s *discordgo.Session, i *discordgo.InteractionCreate, args []string) {
+	b.pendingLogs.take(args[0])
+	b.closeElogPreview(s, i, utils.CreateEmbed("Cancelled", "Nothing was logged."))
+}
+
+// closeElogPreview replaces the preview with the embed, removing its buttons
+func (b *Bot) closeElogPreview(s *discordgo.Session, i *discordgo.InteractionCreate, embed *discordgo.MessageEmbed) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: []discordgo.MessageComponent{},
+		},
+	})
+	if err != nil {
+		log.Printf("Error updating preview: %v", err)
+	}
+}
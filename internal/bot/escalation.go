@@ -0,0 +1,167 @@
+package bot
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/shininglegend/shieldbot/internal/cases"
+	"github.com/shininglegend/shieldbot/pkg/utils"
+)
+
+// Discord doesn't allow timeouts longer than this
+const maxTimeout = 28 * 24 * time.Hour
+
+// activePointsSince returns the time before which cases no longer count towards a user's points
+func (b *Bot) activePointsSince(guildID string) time.Time {
+	days, err := b.pm.GetPointDecayDays(guildID)
+	if err != nil || days <= 0 {
+		return time.Time{} // Points never decay
+	}
+	return time.Now().AddDate(0, 0, -days)
+}
+
+// escalate applies the highest threshold that case c pushed the user past, on behalf of the member who triggered the interaction.
+// The escalation is logged as an automatic case linked to c.
+func (b *Bot) escalate(s *discordgo.Session, i *discordgo.InteractionCreate, user *discordgo.User, c *cases.Case) utils.Messages {
+	messages := utils.Messages{}
+	if c.Points <= 0 {
+		return messages
+	}
+
+	total, err := b.cm.ActivePoints(c.GuildID, user.ID, b.activePointsSince(c.GuildID))
+	if err != nil {
+		log.Printf("Error fetching points: %v", err)
+		messages.AddMessage("Could not check escalation thresholds.")
+		return messages
+	}
+	thresholds, err := b.cm.GetThresholds(c.GuildID)
+	if err != nil {
+		log.Printf("Error fetching thresholds: %v", err)
+		messages.AddMessage("Could not check escalation thresholds.")
+		return messages
+	}
+
+	// Only the highest threshold crossed by this case applies
+	before := total - c.Points
+	var crossed *cases.Threshold
+	for idx := range thresholds {
+		if before < thresholds[idx].Points && thresholds[idx].Points <= total {
+			crossed = &thresholds[idx]
+		}
+	}
+	messages.AddMessage(fmt.Sprintf("%v now has %v active point(s).", user.Mention(), total))
+	if crossed == nil {
+		return messages
+	}
+
+	// The case itself may already be the action, and isolating twice fails
+	if crossed.Action == c.Action {
+		messages.AddMessage(fmt.Sprintf("Reached the %v point threshold for %v, which this case already is.", crossed.Points, crossed.Action))
+		return messages
+	}
+	if crossed.Action == actionIsolate && b.isIsolated(c.GuildID, user.ID) {
+		messages.AddMessage(fmt.Sprintf("Reached the %v point threshold for %v, but %v is already isolated.", crossed.Points, crossed.Action, user.Mention()))
+		return messages
+	}
+
+	reason := fmt.Sprintf("Automatic escalation: reached %v points (threshold %v), see case #%v", total, crossed.Points, c.Number)
+
+	// The escalation is done on the moderator's behalf, so they must be allowed to do it themselves
+	if e := b.authorizeEscalation(s, i, user, crossed.Action); e != nil {
+		why := strings.Join(strings.Fields(e.Title+" "+e.Description), " ")
+		messages.AddMessage(fmt.Sprintf("**Escalation to %v not applied:** %v Someone allowed to %v this user should apply it.", crossed.Action, why, crossed.Action))
+		return messages
+	}
+	if crossed.Action != actionIsolate {
+		// Logged below, not as a native action
+		b.tracker.expect(c.GuildID, user.ID, crossed.Action)
+	}
+	switch crossed.Action {
+	case actionTimeout:
+		until := time.Now().Add(min(crossed.Duration, maxTimeout))
+		err = s.GuildMemberTimeout(c.GuildID, user.ID, &until)
+	case actionIsolate:
+		isolateMessages, errEmbed := b.isolateUser(s, i, user, reason, "Until further notice")
+		if errEmbed != nil {
+			err = fmt.Errorf("%v %v", errEmbed.Title, errEmbed.Description)
+		}
+		messages = append(messages, isolateMessages...)
+	case actionKick:
+		err = s.GuildMemberDeleteWithReason(c.GuildID, user.ID, reason)
+	case actionBan:
+		err = s.GuildBanCreateWithReason(c.GuildID, user.ID, reason, 0)
+	default:
+		err = fmt.Errorf("unknown escalation action: %v", crossed.Action)
+	}
+	if err != nil {
+		log.Printf("Error applying escalation: %v", err)
+		b.tracker.release(c.GuildID, user.ID, crossed.Action)
+		messages.AddMessage(fmt.Sprintf("**Escalation to %v failed:** %v. Please apply it manually.", crossed.Action, err))
+		return messages
+	}
+	// A permanent ban replaces any scheduled unban
+	if crossed.Action == actionBan {
+		if err := b.cm.RemoveTempBan(c.GuildID, user.ID); err != nil {
+			log.Printf("Error removing temporary ban: %v", err)
+		}
+	}
+
+	auto := &cases.Case{
+		GuildID:     c.GuildID,
+		ModeratorID: s.State.User.ID,
+		TargetID:    user.ID,
+		Action:      crossed.Action,
+		Reason:      reason,
+		CreatedAt:   time.Now(),
+		LinkedCase:  c.Number,
+	}
+	err = b.recordCase(s, auto, nil)
+	if err != nil {
+		log.Printf("Error logging escalation: %v", err)
+		messages.AddMessage(fmt.Sprintf("**Escalated to %v**, but the automatic case could not be logged.", crossed.Action))
+		return messages
+	}
+	messages.AddMessage(fmt.Sprintf("**Escalated to %v** as case #%v.", crossed.Action, auto.Number))
+	return messages
+}
+
+// authorizeEscalation checks that the member who triggered the interaction may apply the escalation action to the user,
+// the same way executeAction does. Isolations check the role hierarchy themselves.
+func (b *Bot) authorizeEscalation(s *discordgo.Session, i *discordgo.InteractionCreate, user *discordgo.User, action string) *discordgo.MessageEmbed {
+	if e := b.authorizeAction(s, i, action); e != nil {
+		return e
+	}
+	if action == actionIsolate {
+		return nil
+	}
+
+	guild, err := s.Guild(i.GuildID)
+	if err != nil {
+		log.Printf("Error fetching guild: %v", err)
+		return utils.CreateErrorEmbed(s, i, "Failed to fetch guild", err)
+	}
+	member, err := s.GuildMember(i.GuildID, user.ID)
+	if err != nil {
+		// Banning someone who left needs no hierarchy check, everything else fails without a member anyway
+		if utils.CheckError(err, discordgo.ErrCodeUnknownMember) {
+			return nil
+		}
+		log.Printf("Error fetching member: %v", err)
+		return utils.CreateErrorEmbed(s, i, "Failed to fetch member", err)
+	}
+	return checkHierarchy(s, i, guild, member, action)
+}
+
+// isIsolated reports whether the bot has saved roles for the user, meaning they're isolated
+func (b *Bot) isIsolated(guildID, userID string) bool {
+	var exists int
+	err := b.db.QueryRow("SELECT 1 FROM user_roles WHERE user_id = ? AND guild_id = ?", userID, guildID).Scan(&exists)
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("Error fetching roles: %v", err)
+	}
+	return err == nil
+}
@@ -4,29 +4,42 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"strings"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
+	"github.com/shininglegend/shieldbot/internal/logging"
 	"github.com/shininglegend/shieldbot/pkg/utils"
 )
 
+// Accounts younger than this are flagged when they join
+const newAccountAge = 7 * 24 * time.Hour
+
 // HandleJoin processes new member join events
 func (b *Bot) HandleJoin(s *discordgo.Session, m *discordgo.GuildMemberAdd) {
 	// If data is missing or they're a bot, ignore it.
 	if m.User.Bot || m.Member == nil || m.Member.User == nil || m.Member.User.ID == "" {
 		return
 	}
+	b.members.add(m.GuildID, m.Member)
+
 	// Check if the user was isolated
 	var roleIDs string
+	isolated := true
 	err := b.db.QueryRow("SELECT roles FROM user_roles WHERE user_id = ? AND guild_id = ?", m.Member.User.ID, m.GuildID).Scan(&roleIDs)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			// If they weren't isolated, do nothing
-			return
+			isolated = false
 		} else {
 			log.Printf("Error fetching roles: %v", err)
 			utils.SendToDevChannelDMs(s, fmt.Sprintf("Error fetching roles: %v", err), 1)
 		}
 	}
+	b.logMemberJoin(s, m.Member, isolated)
+	// If they weren't isolated, do nothing
+	if !isolated {
+		return
+	}
 
 	// If they were isolated, remove their roles and add the isolation role back
 	isolationRole, err := b.pm.GetIsolationRoleID(m.GuildID)
@@ -52,6 +65,126 @@ func (b *Bot) HandleJoin(s *discordgo.Session, m *discordgo.GuildMemberAdd) {
 
 // HandleLeave processes member leave events
 func (b *Bot) HandleLeave(s *discordgo.Session, m *discordgo.GuildMemberRemove) {
-	// Nothing for now
-	return
+	if m.Member == nil || m.User == nil || m.User.Bot {
+		return
+	}
+	snapshot, known := b.members.take(m.GuildID, m.User.ID)
+
+	var exists int
+	err := b.db.QueryRow("SELECT 1 FROM user_roles WHERE user_id = ? AND guild_id = ?", m.User.ID, m.GuildID).Scan(&exists)
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("Error fetching roles: %v", err)
+	}
+	isolated := err == nil
+
+	embed := b.memberEmbed(m.GuildID, m.User, isolated)
+	embed.Title = "Member Left"
+	embed.Color = 0xFFA500 // Orange
+	if known {
+		roles := []string{}
+		for _, roleID := range snapshot.roles {
+			roles = append(roles, fmt.Sprintf("<@&%v>", roleID))
+		}
+		rolesValue := "None"
+		if len(roles) > 0 {
+			rolesValue = truncate(strings.Join(roles, " "), 1024)
+		}
+		embed.Fields = append(embed.Fields,
+			&discordgo.MessageEmbedField{
+				Name:   "Joined",
+				Value:  fmt.Sprintf("<t:%v:f>", snapshot.joinedAt.Unix()),
+				Inline: true,
+			},
+			&discordgo.MessageEmbedField{
+				Name:   "Time in Server",
+				Value:  utils.FormatDuration(time.Since(snapshot.joinedAt)),
+				Inline: true,
+			},
+			&discordgo.MessageEmbedField{
+				Name:  "Roles",
+				Value: rolesValue,
+			},
+		)
+	} else {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  "Roles",
+			Value: "*Unknown, they joined while the bot was offline*",
+		})
+	}
+
+	err = b.logger.SendEmbed(s, m.GuildID, logging.CategoryMembers, embed)
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("Error logging member leave: %v", err)
+	}
+}
+
+// logMemberJoin posts a joining member to the member log
+func (b *Bot) logMemberJoin(s *discordgo.Session, m *discordgo.Member, isolated bool) {
+	embed := b.memberEmbed(m.GuildID, m.User, isolated)
+	embed.Title = "Member Joined"
+	embed.Color = 0x00FF00 // Green
+	err := b.logger.SendEmbed(s, m.GuildID, logging.CategoryMembers, embed)
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("Error logging member join: %v", err)
+	}
+}
+
+// memberEmbed builds the part of a join or leave log shared by both: the account age and any flags
+func (b *Bot) memberEmbed(guildID string, user *discordgo.User, isolated bool) *discordgo.MessageEmbed {
+	created, err := discordgo.SnowflakeTimestamp(user.ID)
+	if err != nil {
+		log.Printf("Error reading account age: %v", err)
+	}
+	flags := b.memberFlags(guildID, user, created, isolated)
+	flagsValue := "None"
+	if len(flags) > 0 {
+		flagsValue = strings.Join(flags, "\n")
+	}
+	return &discordgo.MessageEmbed{
+		Description: fmt.Sprintf("%v `%v` (%v)", user.Mention(), user.ID, user.Username),
+		Thumbnail:   &discordgo.MessageEmbedThumbnail{URL: user.AvatarURL("")},
+		Timestamp:   time.Now().Format(time.RFC3339),
+		Fields: []*discordgo.MessageEmbedField{
+			{
+				Name:   "Account Created",
+				Value:  fmt.Sprintf("<t:%v:f> (<t:%v:R>)", created.Unix(), created.Unix()),
+				Inline: true,
+			},
+			{
+				Name:  "Flags",
+				Value: flagsValue,
+			},
+		},
+	}
+}
+
+// memberFlags lists anything moderators may want to look into about a member
+func (b *Bot) memberFlags(guildID string, user *discordgo.User, created time.Time, isolated bool) []string {
+	var flags []string
+	if age := time.Since(created); age < newAccountAge {
+		flags = append(flags, fmt.Sprintf("New account, created %v ago", utils.FormatDuration(age)))
+	}
+	if user.Avatar == "" {
+		flags = append(flags, "Default avatar")
+	}
+
+	userCases, err := b.cm.GetUserCases(guildID, user.ID)
+	if err != nil {
+		log.Printf("Error fetching cases: %v", err)
+	}
+	wasIsolated := isolated
+	for _, c := range userCases {
+		if c.Action == actionIsolate {
+			wasIsolated = true
+		}
+	}
+	if isolated {
+		flags = append(flags, "Currently isolated")
+	} else if wasIsolated {
+		flags = append(flags, "Previously isolated")
+	}
+	if len(userCases) > 0 {
+		flags = append(flags, fmt.Sprintf("Has %v prior case(s), see /history", len(userCases)))
+	}
+	return flags
 }
@@ -0,0 +1,100 @@
+package bot
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/shininglegend/shieldbot/internal/cases"
+	"github.com/shininglegend/shieldbot/pkg/utils"
+)
+
+const (
+	// Number of evidenceN options on /log and /elog
+	maxEvidence = 3
+	// Discord's upload limit for bots without boosts
+	maxEvidenceBytes = 25 * 1024 * 1024
+)
+
+var evidenceClient = &http.Client{Timeout: 30 * time.Second}
+
+// evidenceOptions are the optional file uploads of /log and /elog
+func evidenceOptions() []*discordgo.ApplicationCommandOption {
+	options := make([]*discordgo.ApplicationCommandOption, maxEvidence)
+	for n := range options {
+		options[n] = &discordgo.ApplicationCommandOption{
+			Type:        discordgo.ApplicationCommandOptionAttachment,
+			Name:        fmt.Sprintf("evidence%v", n+1),
+			Description: "A screenshot or file to attach to the log",
+			Required:    false,
+		}
+	}
+	return options
+}
+
+// evidenceAttachments returns the files uploaded with the evidence options
+func evidenceAttachments(i *discordgo.InteractionCreate) []*discordgo.MessageAttachment {
+	data := i.ApplicationCommandData()
+	var attachments []*discordgo.MessageAttachment
+	for n := 1; n <= maxEvidence; n++ {
+		opt := utils.FindOption(data.Options, fmt.Sprintf("evidence%v", n))
+		if opt == nil || data.Resolved == nil {
+			continue
+		}
+		if a, ok := data.Resolved.Attachments[opt.Value.(string)]; ok {
+			attachments = append(attachments, a)
+		}
+	}
+	return attachments
+}
+
+// downloadEvidence fetches the uploaded files so they can be re-uploaded with the mod log message,
+// since the links of files uploaded to a command don't last.
+func downloadEvidence(attachments []*discordgo.MessageAttachment) ([]*discordgo.File, error) {
+	total := 0
+	for _, a := range attachments {
+		total += a.Size
+	}
+	if total > maxEvidenceBytes {
+		return nil, fmt.Errorf("evidence is %v MB, the limit is %v MB", total/1024/1024, maxEvidenceBytes/1024/1024)
+	}
+
+	files := make([]*discordgo.File, 0, len(attachments))
+	for _, a := range attachments {
+		resp, err := evidenceClient.Get(a.URL)
+		if err != nil {
+			return nil, fmt.Errorf("error downloading %v: %w", a.Filename, err)
+		}
+		data, err := io.ReadAll(io.LimitReader(resp.Body, maxEvidenceBytes))
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("error downloading %v: %w", a.Filename, err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("error downloading %v: %v", a.Filename, resp.Status)
+		}
+		files = append(files, &discordgo.File{
+			Name:        a.Filename,
+			ContentType: a.ContentType,
+			Reader:      bytes.NewReader(data),
+		})
+	}
+	return files, nil
+}
+
+// caseAttachments converts the files of a mod log message into case attachments
+func caseAttachments(msg *discordgo.Message) []cases.Attachment {
+	attachments := make([]cases.Attachment, 0, len(msg.Attachments))
+	for _, a := range msg.Attachments {
+		attachments = append(attachments, cases.Attachment{
+			Filename:    a.Filename,
+			URL:         a.URL,
+			ContentType: a.ContentType,
+			Size:        a.Size,
+		})
+	}
+	return attachments
+}
@@ -0,0 +1,77 @@
+package bot
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/shininglegend/shieldbot/internal/cases"
+	"github.com/shininglegend/shieldbot/internal/export"
+	"github.com/shininglegend/shieldbot/pkg/auth"
+	"github.com/shininglegend/shieldbot/pkg/utils"
+)
+
+// Subcommands of /export
+const exportCases = "cases"
+
+// handleExport uploads the guild's mod cases and isolations as files
+func (b *Bot) handleExport(s *discordgo.Session, i *discordgo.InteractionCreate) (*discordgo.MessageEmbed, []*discordgo.File) {
+	if e := auth.QuickAuthAdminOrOverride(b.pm, s, i); e != nil {
+		return e, nil
+	}
+	options := i.ApplicationCommandData().Options[0].Options
+	format := options[0].StringValue()
+
+	filter := cases.Filter{GuildID: i.GuildID}
+	if opt := utils.FindOption(options, "user"); opt != nil {
+		filter.UserID = opt.UserValue(nil).ID
+	}
+	if opt := utils.FindOption(options, "action"); opt != nil {
+		filter.Action = opt.StringValue()
+	}
+	if opt := utils.FindOption(options, "from"); opt != nil {
+		from, err := export.ParseDate(opt.StringValue())
+		if err != nil {
+			return utils.CreateNotAllowedEmbed("Invalid date", "Use dates like `2024-01-31`."), nil
+		}
+		filter.From = from
+	}
+	if opt := utils.FindOption(options, "to"); opt != nil {
+		to, err := export.ParseDate(opt.StringValue())
+		if err != nil {
+			return utils.CreateNotAllowedEmbed("Invalid date", "Use dates like `2024-01-31`."), nil
+		}
+		// Include the whole last day
+		filter.To = to.AddDate(0, 0, 1)
+	}
+
+	e, err := export.Load(b.db, b.cm, filter)
+	if err != nil {
+		return utils.CreateErrorEmbed(s, i, "Failed to export cases", err), nil
+	}
+
+	name := fmt.Sprintf("cases-%v-%v", i.GuildID, time.Now().UTC().Format("2006-01-02"))
+	var files []*discordgo.File
+	switch format {
+	case export.FormatJSON:
+		var buf bytes.Buffer
+		if err := e.WriteJSON(&buf); err != nil {
+			return utils.CreateErrorEmbed(s, i, "Failed to export cases", err), nil
+		}
+		files = append(files, &discordgo.File{Name: name + ".json", ContentType: "application/json", Reader: &buf})
+	default:
+		var casesBuf, isolationsBuf bytes.Buffer
+		if err := e.WriteCasesCSV(&casesBuf); err != nil {
+			return utils.CreateErrorEmbed(s, i, "Failed to export cases", err), nil
+		}
+		if err := e.WriteIsolationsCSV(&isolationsBuf); err != nil {
+			return utils.CreateErrorEmbed(s, i, "Failed to export isolations", err), nil
+		}
+		files = append(files,
+			&discordgo.File{Name: name + ".csv", ContentType: "text/csv", Reader: &casesBuf},
+			&discordgo.File{Name: fmt.Sprintf("isolations-%v.csv", i.GuildID), ContentType: "text/csv", Reader: &isolationsBuf},
+		)
+	}
+	return utils.CreateEmbed("Export ready", fmt.Sprintf("Exported %v case(s) and %v current isolation(s).", len(e.Cases), len(e.Isolations))), files
+}
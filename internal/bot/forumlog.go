@@ -0,0 +1,89 @@
+// This file posts the mod log to a forum channel, with one post per user, if the moderation log channel is a forum
+package bot
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/shininglegend/shieldbot/internal/logging"
+	"github.com/shininglegend/shieldbot/pkg/utils"
+)
+
+// sendUserLog posts a moderation log message about a user.
+// In a forum, it goes in the user's own post, which is started the first time it's needed.
+// Returns sql.ErrNoRows if the guild has no log channel.
+func (b *Bot) sendUserLog(s *discordgo.Session, guildID, userID string, msg *discordgo.MessageSend) (*discordgo.Message, error) {
+	channelID, err := b.logger.ChannelID(guildID, logging.CategoryModeration)
+	if err != nil {
+		return nil, err
+	}
+	channel, err := fetchChannel(s, channelID)
+	if err != nil {
+		return nil, err
+	}
+	if channel.Type != discordgo.ChannelTypeGuildForum {
+		// Makes the log channel searchable by user ID
+		msg.Content = fmt.Sprintf("User ID: %v", userID)
+		return s.ChannelMessageSendComplex(channelID, msg)
+	}
+
+	threadID, err := b.userPost(s, guildID, channelID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.ChannelMessageSendComplex(threadID, msg)
+}
+
+// userPost returns the forum post of a user, starting a new one if they don't have one or it was deleted
+func (b *Bot) userPost(s *discordgo.Session, guildID, forumID, userID string) (string, error) {
+	threadID, err := b.cm.GetUserPost(guildID, forumID, userID)
+	if err != nil && err != sql.ErrNoRows {
+		return "", err
+	}
+	if err == nil {
+		_, err = fetchChannel(s, threadID)
+		if err == nil {
+			return threadID, nil
+		}
+		if !utils.CheckError(err, discordgo.ErrCodeUnknownChannel) {
+			return "", err
+		}
+	}
+
+	// Titled with the username and ID so the post can be found through the forum's search
+	title := userID
+	avatarURL := ""
+	if user, err := s.User(userID); err == nil {
+		title = fmt.Sprintf("%v (%v)", user.Username, user.ID)
+		avatarURL = user.AvatarURL("")
+	}
+	thread, err := s.ForumThreadStartComplex(forumID, &discordgo.ThreadStart{
+		Name: truncate(title, 100),
+	}, &discordgo.MessageSend{
+		Embed: &discordgo.MessageEmbed{
+			Title:       "Mod Log",
+			Description: fmt.Sprintf("Every case for <@%v> `%v` is posted in this thread.", userID, userID),
+			Color:       0x0000FF, // Blue
+			Timestamp:   time.Now().Format(time.RFC3339),
+			Thumbnail:   &discordgo.MessageEmbedThumbnail{URL: avatarURL},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("error starting forum post: %w", err)
+	}
+	err = b.cm.SetUserPost(guildID, forumID, userID, thread.ID)
+	if err != nil {
+		return "", fmt.Errorf("error saving forum post: %w", err)
+	}
+	return thread.ID, nil
+}
+
+// fetchChannel returns a channel from the state, or from Discord if it isn't there, like archived threads
+func fetchChannel(s *discordgo.Session, channelID string) (*discordgo.Channel, error) {
+	if channel, err := s.State.Channel(channelID); err == nil {
+		return channel, nil
+	}
+	return s.Channel(channelID)
+}
@@ -11,6 +11,16 @@ import (
 )
 
 func (b *Bot) handleCommands(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	// Buttons and modals are handled in handleComponents
+	if i.Type != discordgo.InteractionApplicationCommand {
+		return
+	}
+
+	// Some commands answer with a modal, which has to be the first response
+	if b.handleModalCommands(s, i) {
+		return
+	}
+
 	// Acknowledge the interaction immediately
 	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
@@ -25,6 +35,8 @@ func (b *Bot) handleCommands(s *discordgo.Session, i *discordgo.InteractionCreat
 
 	// Process the command
 	var embed *discordgo.MessageEmbed
+	var components []discordgo.MessageComponent
+	var files []*discordgo.File
 	var privateResponse bool = true
 	switch n := i.ApplicationCommandData().Name; n {
 	case cmdPingType:
@@ -41,19 +53,60 @@ func (b *Bot) handleCommands(s *discordgo.Session, i *discordgo.InteractionCreat
 	case cmdLogging:
 		embed = b.handleLogging(s, i) // Needs manage messages permissions
 	case cmdLoggingExt:
-		embed = b.handleLoggingExternal(s, i) // Needs manage messages permissions
+		embed, components = b.handleLoggingExternal(s, i) // Needs manage messages permissions
 	case cmdRestore:
 		embed = b.handleRestore(s, i) // Needs manage roles permissions
 		privateResponse = false
+	case cmdReply:
+		embed = b.handleModmailReply(s, i) // Needs manage messages permissions
+		privateResponse = false
+	case cmdClose:
+		embed = b.handleModmailClose(s, i) // Needs manage messages permissions
+	case cmdCase:
+		embed = b.handleCase(s, i) // Needs manage messages permissions, or admin to delete
+	case cmdTimeout, cmdKick, cmdBan, cmdTempBan:
+		embed = b.handleModeration(s, i, n) // Needs the matching moderation permission
+		privateResponse = false
+	case cmdUnban:
+		embed = b.handleUnban(s, i) // Needs ban members permissions
+		privateResponse = false
+	case cmdPurge:
+		embed = b.handlePurge(s, i) // Needs manage messages permissions
+	case cmdNote:
+		embed = b.handleNote(s, i) // Needs manage messages permissions
+	case cmdModStats:
+		embed = b.handleModStats(s, i) // Needs admin permissions
+	case cmdExport:
+		embed, files = b.handleExport(s, i) // Needs admin permissions
+	case cmdHistory, cmdHistoryMenu:
+		embed, components = b.handleHistory(s, i) // Needs manage messages permissions
+	case cmdWhois:
+		embed, components = b.handleWhois(s, i) // Needs manage messages permissions
+	case cmdRules:
+		embed = b.handleRules(s, i) // Needs admin permissions to change
+		privateResponse = i.ApplicationCommandData().Options[0].Name != rulesList
 	default:
 		embed = utils.CreateNotAllowedEmbed("Unknown command", fmt.Sprintf("Unknown command: %v", n))
 	}
 
 	// Edit the original response with the command output
-	b.editResponseEmbed(s, i, privateResponse, embed)
+	b.editResponseEmbed(s, i, privateResponse, embed, components, files)
 }
 
-func (b *Bot) editResponseEmbed(s *discordgo.Session, i *discordgo.InteractionCreate, private bool, embed *discordgo.MessageEmbed) {
+// handleModalCommands opens a modal for commands that need one, returning false if the command doesn't
+func (b *Bot) handleModalCommands(s *discordgo.Session, i *discordgo.InteractionCreate) bool {
+	data := i.ApplicationCommandData()
+	switch data.Name {
+	case cmdLogging:
+		if opt := utils.FindOption(data.Options, "form"); opt != nil && opt.BoolValue() {
+			b.handleLogFormOpen(s, i)
+			return true
+		}
+	}
+	return false
+}
+
+func (b *Bot) editResponseEmbed(s *discordgo.Session, i *discordgo.InteractionCreate, private bool, embed *discordgo.MessageEmbed, components []discordgo.MessageComponent, files []*discordgo.File) {
 	if !private {
 		// For non-private responses, create a new follow-up message without ephemeral flag
 		err := s.InteractionResponseDelete(i.Interaction)
@@ -62,7 +115,9 @@ func (b *Bot) editResponseEmbed(s *discordgo.Session, i *discordgo.InteractionCr
 			return
 		}
 		_, err = s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
-			Embeds: []*discordgo.MessageEmbed{embed},
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: components,
+			Files:      files,
 		})
 		if err != nil {
 			log.Printf("Error creating follow-up message: %v", err)
@@ -71,9 +126,14 @@ func (b *Bot) editResponseEmbed(s *discordgo.Session, i *discordgo.InteractionCr
 	}
 
 	// For private responses, edit the original ephemeral message
-	_, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+	edit := &discordgo.WebhookEdit{
 		Embeds: &[]*discordgo.MessageEmbed{embed},
-	})
+		Files:  files,
+	}
+	if components != nil {
+		edit.Components = &components
+	}
+	_, err := s.InteractionResponseEdit(i.Interaction, edit)
 	if err != nil {
 		log.Printf("Error editing interaction response: %v", err)
 	}
@@ -97,19 +157,75 @@ func (b *Bot) handleHelp(_ *discordgo.Session, _ *discordgo.InteractionCreate) *
 		// Isolate and restore
 		{
 			Name: "Isolation Commands",
-			Value: "/isolate - Isolate a user in the guild\n" +
+			Value: "/isolate - Isolate a user in the guild, optionally restoring them after a duration\n" +
 				"/restore - Restore a user in the guild\n",
 			Inline: false,
 		},
+		// Moderation
+		{
+			Name: "Moderation Commands",
+			Value: "/timeout - Time out a member\n" +
+				"/kick - Kick a member\n" +
+				"/ban - Permanently ban a user\n" +
+				"/tempban - Ban a user for a set time\n" +
+				"/unban - Unban a user\n" +
+				"These DM the user and log the action for you.\n" +
+				"/purge - Bulk delete messages, filtered by user, text, bots, attachments or message ID\n",
+			Inline: false,
+		},
+		// Logging and cases
+		{
+			Name: "Logging Commands",
+			Value: "/log - Log a moderator action on a member, optionally having the bot perform it\n" +
+				"/elog - Log a moderator action on one or more users by ID, mention or username, after a preview\n" +
+				"Both take up to 3 evidence files, attached to the log message\n" +
+				"/log form:True - Open a form for a longer reason, details and evidence links\n" +
+				"/case view - View a logged case\n" +
+				"/case reason - Change the reason of a case\n" +
+				"/case delete - Delete a case\n" +
+				"/history - List a user's logged cases and notes (also in the user context menu)\n" +
+				"/note add, list, remove - Keep staff-only notes on a user, which aren't cases\n" +
+				"/rules add, list, remove - Keep numbered server rules, suggested as reasons by /log, /elog and /isolate\n" +
+				"/rules preset add, remove - Save reasons to suggest too, optionally citing a rule\n" +
+				"/whois - See a user's account age, roles, isolation, timeout and case count, with buttons to act\n" +
+				"/export cases - Download cases and isolations as CSV or JSON\n" +
+				"/modstats - See how many cases each moderator logged\n",
+			Inline: false,
+		},
+		// Modmail
+		{
+			Name: "Modmail Commands",
+			Value: "/reply - Reply to the user of a modmail thread, optionally anonymously\n" +
+				"/close - Close a modmail thread and save the transcript to the log channel\n",
+			Inline: false,
+		},
 		// Config commands
 		{
 			Name: "Config Commands",
 			Value: "/config setisolationrole - Set the isolation role for the guild\n" +
 				"/config viewperms - View the permissions of commands for the guild\n" +
+				"/config setlogchannel - Set the log channel, or give one category of logs (moderation, members, messages, config, AutoMod, errors) its own\n" +
+				"A forum as the moderation log channel gives each user their own post\n" +
+				"/config casethreads - Open a discussion thread on each mod log entry\n" +
+				"/config setappealmessage - Set the message DMed to isolated users\n" +
+				"/config setappealschannel - Set the channel where isolation appeals are reviewed\n" +
+				"/config setmodmailchannel - Set the channel where modmail threads are opened\n" +
+				"/config actions add, remove, list - Manage the server's own action types for logging\n" +
+				"/config auditlog - See who changed the bot's configuration, and when\n" +
 				"/config addperm - Set the permission override for a command for a role\n" +
 				"/config removeperm - Remove the permission override for a command for a role\n",
 			Inline: false,
 		},
+		// Warning points
+		{
+			Name: "Warning Point Commands",
+			Value: "/config viewpoints - View warning point weights and escalation thresholds\n" +
+				"/config setpoints - Set how many points a logged action adds\n" +
+				"/config addthreshold - Automatically act when a member reaches a number of points\n" +
+				"/config removethreshold - Remove an escalation threshold\n" +
+				"/config setpointdecay - Set how long points count\n",
+			Inline: false,
+		},
 	}
 	embed.Fields = append(embed.Fields, info...)
 	return embed
@@ -121,7 +237,7 @@ func (b *Bot) handleDMMessage(s *discordgo.Session, m *discordgo.MessageCreate)
 		return
 	}
 
-	// Check if the message is a DM and from the dev channel
+	// Check if the message is a DM
 	channel, err := s.Channel(m.ChannelID)
 	if err != nil {
 		log.Printf("Error getting channel: %v", err)
@@ -132,18 +248,8 @@ func (b *Bot) handleDMMessage(s *discordgo.Session, m *discordgo.MessageCreate)
 		return
 	}
 
-	// if the message isn't from the dev channel, send it to the dev channel
-	if channel.ID != utils.GetDevChannel(s) {
-		_, err = s.ChannelMessageSend(utils.GetDevChannel(s), fmt.Sprintf("Message from %v (`%v`): %v", m.Author.Username, m.Author.ID, m.Content))
-		if err != nil {
-			log.Printf("Error sending message to dev channel: %v", err)
-			err = nil
-		}
-		return
-	}
-
-	// Check if the message is "refresh"
-	if strings.ToLower(m.Content) == "refresh" {
+	// Check if the developer sent "refresh"
+	if channel.ID == utils.GetDevChannel(s) && strings.ToLower(m.Content) == "refresh" {
 		log.Printf("Refreshing commands")
 		err := b.RefreshCommands()
 		if err != nil {
@@ -152,5 +258,12 @@ func (b *Bot) handleDMMessage(s *discordgo.Session, m *discordgo.MessageCreate)
 		} else {
 			s.ChannelMessageSend(m.ChannelID, "Commands refreshed successfully!")
 		}
+		return
+	}
+
+	// Everything else is modmail, handled by the staff of the chosen guild
+	if b.relayModmail(s, m) {
+		return
 	}
+	b.promptDM(s, m)
 }
@@ -0,0 +1,162 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/shininglegend/shieldbot/pkg/auth"
+	"github.com/shininglegend/shieldbot/pkg/utils"
+)
+
+// Number of cases shown per page of /history
+const historyPageSize = 5
+
+func (b *Bot) handleHistory(s *discordgo.Session, i *discordgo.InteractionCreate) (*discordgo.MessageEmbed, []discordgo.MessageComponent) {
+	// The context menu and the page buttons share the /history override
+	if e := auth.QuickAuthManageMessagesOrOverrideFor(b.pm, s, i, cmdHistory); e != nil {
+		return e, nil
+	}
+
+	// Works both as a slash command and from the user context menu
+	data := i.ApplicationCommandData()
+	var user *discordgo.User
+	if data.TargetID != "" {
+		user = data.Resolved.Users[data.TargetID]
+	} else {
+		user = data.Options[0].UserValue(s)
+	}
+	if user == nil {
+		return utils.CreateNotAllowedEmbed("User not found", "Could not find that user."), nil
+	}
+	return b.historyPage(s, i, user, 0)
+}
+
+// handleHistoryPage switches between pages of a /history response
+func (b *Bot) handleHistoryPage(s *discordgo.Session, i *discordgo.InteractionCreate, args []string) {
+	if e := auth.QuickAuthManageMessagesOrOverrideFor(b.pm, s, i, cmdHistory); e != nil {
+		respondEphemeral(s, i, e)
+		return
+	}
+	user, err := s.User(args[0])
+	if err != nil {
+		respondEphemeral(s, i, utils.CreateErrorEmbed(s, i, "Failed to fetch user", err))
+		return
+	}
+	page, _ := strconv.Atoi(args[1])
+
+	embed, components := b.historyPage(s, i, user, page)
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: components,
+		},
+	})
+	if err != nil {
+		log.Printf("Error updating history: %v", err)
+	}
+}
+
+// historyPage builds one page of a user's case history, with buttons to switch pages
+func (b *Bot) historyPage(s *discordgo.Session, i *discordgo.InteractionCreate, user *discordgo.User, page int) (*discordgo.MessageEmbed, []discordgo.MessageComponent) {
+	userCases, err := b.cm.GetUserCases(i.GuildID, user.ID)
+	if err != nil {
+		return utils.CreateErrorEmbed(s, i, "Failed to fetch history", err), nil
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:     fmt.Sprintf("History for %v", user.Username),
+		Color:     0x0000FF, // Blue
+		Thumbnail: &discordgo.MessageEmbedThumbnail{URL: user.AvatarURL("")},
+	}
+	// Notes aren't cases, but belong with them
+	notes := b.historyNotes(i.GuildID, user.ID)
+	if len(userCases) == 0 {
+		embed.Description = fmt.Sprintf("%v `%v` has no logged cases.", user.Mention(), user.ID)
+		if notes != nil {
+			embed.Fields = append(embed.Fields, notes)
+		}
+		return embed, nil
+	}
+	embed.Description = fmt.Sprintf("%v `%v` has %v logged case(s).", user.Mention(), user.ID, len(userCases))
+
+	// Summary of each action type
+	counts := make(map[string]int)
+	for _, c := range userCases {
+		counts[c.Action]++
+	}
+	var summary []string
+	for _, action := range logActions {
+		if counts[action] > 0 {
+			summary = append(summary, fmt.Sprintf("%v: %v", action, counts[action]))
+			delete(counts, action)
+		}
+	}
+	// Whatever is left are the guild's own actions
+	custom := make([]string, 0, len(counts))
+	for action := range counts {
+		custom = append(custom, action)
+	}
+	sort.Strings(custom)
+	for _, action := range custom {
+		label, _, _ := b.actionType(i.GuildID, action)
+		summary = append(summary, fmt.Sprintf("%v: %v", label, counts[action]))
+	}
+	embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+		Name:  "Summary",
+		Value: strings.Join(summary, "\n"),
+	})
+	if byRule := b.historyRules(i.GuildID, userCases); byRule != nil {
+		embed.Fields = append(embed.Fields, byRule)
+	}
+	if notes != nil {
+		embed.Fields = append(embed.Fields, notes)
+	}
+
+	// The cases on this page
+	pages := (len(userCases) + historyPageSize - 1) / historyPageSize
+	page = max(0, min(page, pages-1))
+	start := page * historyPageSize
+	end := min(start+historyPageSize, len(userCases))
+	for _, c := range userCases[start:end] {
+		reason := c.Reason
+		if reason == "" {
+			reason = "*No reason provided*"
+		}
+		value := fmt.Sprintf("%v\nBy <@%v> <t:%v:R>", truncate(reason, 200), c.ModeratorID, c.CreatedAt.Unix())
+		if c.LogMessageID != "" {
+			value = fmt.Sprintf("%v | [Log](%v)", value, messageLink(c.GuildID, c.LogChannelID, c.LogMessageID))
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  fmt.Sprintf("Case #%v | %v", c.Number, c.Action),
+			Value: value,
+		})
+	}
+	embed.Footer = &discordgo.MessageEmbedFooter{
+		Text: fmt.Sprintf("Page %v of %v", page+1, pages),
+	}
+
+	if pages == 1 {
+		return embed, nil
+	}
+	return embed, []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{
+				Label:    "Previous",
+				Style:    discordgo.SecondaryButton,
+				CustomID: makeCustomID(compHistoryPage, user.ID, strconv.Itoa(page-1)),
+				Disabled: page == 0,
+			},
+			discordgo.Button{
+				Label:    "Next",
+				Style:    discordgo.SecondaryButton,
+				CustomID: makeCustomID(compHistoryPage, user.ID, strconv.Itoa(page+1)),
+				Disabled: page == pages-1,
+			},
+		}},
+	}
+}
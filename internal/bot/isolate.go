@@ -5,22 +5,72 @@ import (
 	"fmt"
 	"log"
 	"strings"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
+	"github.com/shininglegend/shieldbot/internal/cases"
 	"github.com/shininglegend/shieldbot/pkg/auth"
 	"github.com/shininglegend/shieldbot/pkg/utils"
 )
 
+// Sent to isolated users when the guild hasn't set its own appeal message
+const defaultAppealMessage = "You have been isolated in {guild} by {moderator}. If you believe this was a mistake, send me a message to start an appeal."
+
 func (b *Bot) handleIsolate(s *discordgo.Session, i *discordgo.InteractionCreate) *discordgo.MessageEmbed {
 	options := i.ApplicationCommandData().Options
 	user := options[0].UserValue(s)
-	messages := utils.Messages{}
 
 	// Authorize the command
 	if e := auth.QuickAuthManageRolesOrOverride(b.pm, s, i); e != nil {
 		return e
 	}
 
+	durationInput := ""
+	if opt := utils.FindOption(options, "duration"); opt != nil {
+		durationInput = opt.StringValue()
+	}
+	return b.isolateAndLog(s, i, user, optionalReason(options), durationInput)
+}
+
+// isolateAndLog isolates a user and logs it, on behalf of the member who triggered the interaction.
+// Callers must authorize the interaction first. The duration may be empty.
+func (b *Bot) isolateAndLog(s *discordgo.Session, i *discordgo.InteractionCreate, user *discordgo.User, reason, durationInput string) *discordgo.MessageEmbed {
+	// Optional duration, shown to the user and lifted by the scheduler
+	duration := "Until further notice"
+	var d time.Duration
+	if strings.TrimSpace(durationInput) != "" {
+		var err error
+		d, err = utils.ParseDuration(durationInput)
+		if err != nil {
+			return utils.CreateNotAllowedEmbed("Invalid duration", "Use a duration like `30m`, `12h`, `3d` or `2w`.")
+		}
+		duration = utils.FormatDuration(d)
+	}
+
+	messages, errEmbed := b.isolateUser(s, i, user, reason, duration)
+	if errEmbed != nil {
+		return errEmbed
+	}
+
+	// Record it in the mod log
+	c, errEmbed := b.logAction(s, i, user, actionIsolate, reason, nil)
+	if errEmbed != nil {
+		messages.AddMessage(fmt.Sprintf("Could not log the isolation: %v", errEmbed.Description))
+	}
+	if d > 0 {
+		messages = append(messages, b.scheduleRestore(i.GuildID, user, d, c)...)
+	}
+	return utils.CreateEmbed(fmt.Sprintf("User %s (`%v`) has been isolated.", user.Username, user.ID), messages.GetMessages(""))
+}
+
+// isolateUser saves the user's roles and replaces them with the isolation role, on behalf of the member who triggered the interaction.
+// Callers must authorize the interaction first, and log the action. Returns an embed only if the isolation failed.
+func (b *Bot) isolateUser(s *discordgo.Session, i *discordgo.InteractionCreate, user *discordgo.User, reason, duration string) (utils.Messages, *discordgo.MessageEmbed) {
+	messages := utils.Messages{}
+	if reason == "" {
+		reason = "No reason provided."
+	}
+
 	// Ensure the target is not this bot
 	if user.ID == s.State.User.ID {
 		err := s.UpdateGameStatus(0, "Wearing a mask.")
@@ -29,52 +79,43 @@ func (b *Bot) handleIsolate(s *discordgo.Session, i *discordgo.InteractionCreate
 			utils.SendToDevChannelDMs(s, fmt.Sprintf("Error setting status: %v", err), 1)
 			err = nil
 		}
-		return utils.CreateNotAllowedEmbed("Hey! I was being a nice bot :(", "(Fine, I'll put on a mask. But I can't change my roles!)")
-	}
-
-	// Ensure the person issuing the command has a role that is higher than the target's highest
-	issuer, err := s.GuildMember(i.GuildID, i.Member.User.ID)
-	if err != nil {
-		log.Printf("Error fetching issuer member: %v", err)
-		return utils.CreateErrorEmbed(s, i, "Failed to fetch issuer member", err)
+		return nil, utils.CreateNotAllowedEmbed("Hey! I was being a nice bot :(", "(Fine, I'll put on a mask. But I can't change my roles!)")
 	}
 
 	guild, err := s.Guild(i.GuildID)
 	if err != nil {
 		log.Printf("Error fetching guild: %v", err)
-		return utils.CreateErrorEmbed(s, i, "Failed to fetch guild", err)
+		return nil, utils.CreateErrorEmbed(s, i, "Failed to fetch guild", err)
 	}
 
 	member, err := s.GuildMember(i.GuildID, user.ID)
 	if err != nil {
 		if utils.CheckError(err, discordgo.ErrCodeUnknownMember) {
-			return utils.CreateNotAllowedEmbed("User not found", "The user you are trying to isolate is not in this server.")
+			return nil, utils.CreateNotAllowedEmbed("User not found", "The user you are trying to isolate is not in this server.")
 		}
 		log.Printf("Error fetching member: %v", err)
-		return utils.CreateErrorEmbed(s, i, "Failed to fetch member", err)
+		return nil, utils.CreateErrorEmbed(s, i, "Failed to fetch member", err)
 	}
 	// Get isolation role
 	isolationRoleID, err := b.pm.GetIsolationRoleID(i.GuildID)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return utils.CreateNotAllowedEmbed("Isolation role not set.", "Please set it using /config setisolationrole.")
+			return nil, utils.CreateNotAllowedEmbed("Isolation role not set.", "Please set it using /config setisolationrole.")
 		}
 		log.Printf("Error fetching isolation role: %v", err)
-		return utils.CreateErrorEmbed(s, i, "Failed to fetch isolation role", err)
+		return nil, utils.CreateErrorEmbed(s, i, "Failed to fetch isolation role", err)
 	}
 
-	issuerHighestRole := utils.GetHighestRole(issuer.Roles, guild.Roles)
-	targetHighestRole := utils.GetHighestRole(member.Roles, guild.Roles)
-
-	if issuerHighestRole == nil || (targetHighestRole != nil && issuerHighestRole.Position <= targetHighestRole.Position) {
-		return utils.CreateNotAllowedEmbed("Ayo, you can't do that!", "You don't have permission to isolate this user. Your highest role must be higher than the target user's highest role.")
+	// Ensure the person issuing the command has a role that is higher than the target's highest
+	if e := checkHierarchy(s, i, guild, member, "isolate"); e != nil {
+		return nil, e
 	}
 
 	// Save current roles
 	var roleIDs string
 	for _, roleID := range member.Roles {
 		if roleID == isolationRoleID {
-			return utils.CreateNotAllowedEmbed("Already done!", fmt.Sprintf("User %s is already isolated.", user.Mention()))
+			return nil, utils.CreateNotAllowedEmbed("Already done!", fmt.Sprintf("User %s is already isolated.", user.Mention()))
 		}
 		if roleIDs != "" {
 			roleIDs = fmt.Sprintf("%s,%s", roleIDs, roleID)
@@ -90,7 +131,7 @@ func (b *Bot) handleIsolate(s *discordgo.Session, i *discordgo.InteractionCreate
 		user.ID, i.GuildID, roleIDs, roleIDs)
 	if err != nil {
 		log.Printf("Error saving roles: %v", err)
-		return utils.CreateErrorEmbed(s, i, "Error: Failed to save roles to database. Manually isolate the user.", err)
+		return nil, utils.CreateErrorEmbed(s, i, "Error: Failed to save roles to database. Manually isolate the user.", err)
 	}
 
 	// Remove all roles
@@ -120,21 +161,95 @@ func (b *Bot) handleIsolate(s *discordgo.Session, i *discordgo.InteractionCreate
 	err = s.GuildMemberRoleAdd(i.GuildID, user.ID, isolationRoleID)
 	if err != nil {
 		log.Printf("Error adding isolation role: %v", err)
-		return utils.CreateErrorEmbed(s, i, "Failed to add isolation role", err)
+		return nil, utils.CreateErrorEmbed(s, i, "Failed to add isolation role", err)
 	}
-	return utils.CreateEmbed(fmt.Sprintf("User %s (`%v`) has been isolated.", user.Username, user.ID), messages.GetMessages(""))
+
+	// Let the user know why, and how to appeal
+	err = b.notifyIsolatedUser(s, guild, i.Member.User, user, reason, duration)
+	if err != nil {
+		log.Printf("Error sending isolation DM: %v", err)
+		messages.AddMessage(fmt.Sprintf("Could not DM %v about the isolation (their DMs may be closed).", user.Mention()))
+	} else {
+		messages.AddMessage(fmt.Sprintf("Sent %v a DM with the reason and appeal instructions.", user.Mention()))
+	}
+	return messages, nil
+}
+
+// checkHierarchy ensures the member who triggered the interaction has a higher role than the target member
+func checkHierarchy(s *discordgo.Session, i *discordgo.InteractionCreate, guild *discordgo.Guild, member *discordgo.Member, verb string) *discordgo.MessageEmbed {
+	issuer, err := s.GuildMember(i.GuildID, i.Member.User.ID)
+	if err != nil {
+		log.Printf("Error fetching issuer member: %v", err)
+		return utils.CreateErrorEmbed(s, i, "Failed to fetch issuer member", err)
+	}
+
+	issuerHighestRole := utils.GetHighestRole(issuer.Roles, guild.Roles)
+	targetHighestRole := utils.GetHighestRole(member.Roles, guild.Roles)
+
+	if issuerHighestRole == nil || (targetHighestRole != nil && issuerHighestRole.Position <= targetHighestRole.Position) {
+		return utils.CreateNotAllowedEmbed("Ayo, you can't do that!", fmt.Sprintf("You don't have permission to %v this user. Your highest role must be higher than the target user's highest role.", verb))
+	}
+	return nil
+}
+
+// notifyIsolatedUser DMs the user the reason and duration of their isolation, along with the guild's appeal message
+func (b *Bot) notifyIsolatedUser(s *discordgo.Session, guild *discordgo.Guild, moderator, user *discordgo.User, reason, duration string) error {
+	template, err := b.pm.GetAppealMessage(guild.ID)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("Error fetching appeal message: %v", err)
+		}
+		template = defaultAppealMessage
+	}
+	appeal := strings.NewReplacer(
+		"{user}", user.Mention(),
+		"{guild}", guild.Name,
+		"{moderator}", moderator.Username,
+		"{reason}", reason,
+		"{duration}", duration,
+	).Replace(template)
+
+	return utils.SendDM(s, user.ID, &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("You have been isolated in %v", guild.Name),
+		Description: appeal,
+		Color:       0xFFA500, // Orange
+		Timestamp:   time.Now().Format(time.RFC3339),
+		Fields: []*discordgo.MessageEmbedField{
+			{
+				Name:   "Reason",
+				Value:  reason,
+				Inline: false,
+			},
+			{
+				Name:   "Duration",
+				Value:  duration,
+				Inline: true,
+			},
+		},
+	})
 }
 
 func (b *Bot) handleRestore(s *discordgo.Session, i *discordgo.InteractionCreate) *discordgo.MessageEmbed {
 	options := i.ApplicationCommandData().Options
 	user := options[0].UserValue(s)
-	messages := utils.Messages{}
 
 	// Authorize the command
 	if e := auth.QuickAuthManageRolesOrOverride(b.pm, s, i); e != nil {
 		return e
 	}
 
+	messages, errEmbed := b.restoreUser(s, i, user, optionalReason(options))
+	if errEmbed != nil {
+		return errEmbed
+	}
+	return utils.CreateEmbed(fmt.Sprintf("User %s (`%v`) has been restored.", user.Username, user.ID), messages.GetMessages(""))
+}
+
+// restoreUser gives an isolated user their saved roles back, on behalf of the member who triggered the interaction.
+// Callers must authorize the interaction first. Returns an embed only if the restore failed.
+func (b *Bot) restoreUser(s *discordgo.Session, i *discordgo.InteractionCreate, user *discordgo.User, reason string) (utils.Messages, *discordgo.MessageEmbed) {
+	messages := utils.Messages{}
+
 	// Ensure the target is not this bot
 	if user.ID == s.State.User.ID {
 		// Update status:
@@ -144,17 +259,17 @@ func (b *Bot) handleRestore(s *discordgo.Session, i *discordgo.InteractionCreate
 			utils.SendToDevChannelDMs(s, fmt.Sprintf("Error setting status: %v", err), 1)
 			err = nil
 		}
-		return utils.CreateNotAllowedEmbed("Why thank you!", "I'm flattered, but I can't restore myself.")
+		return nil, utils.CreateNotAllowedEmbed("Why thank you!", "I'm flattered, but I can't restore myself.")
 	}
 
 	var roleIDs string
 	err := b.db.QueryRow("SELECT roles FROM user_roles WHERE user_id = ? AND guild_id = ?", user.ID, i.GuildID).Scan(&roleIDs)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return utils.CreateNotAllowedEmbed("Unable to restore", "No roles found to restore. Are you sure this user was isolated using the bot?")
+			return nil, utils.CreateNotAllowedEmbed("Unable to restore", "No roles found to restore. Are you sure this user was isolated using the bot?")
 		} else {
 			log.Printf("Error fetching roles: %v", err)
-			return utils.CreateErrorEmbed(s, i, "Failed to fetch roles", err)
+			return nil, utils.CreateErrorEmbed(s, i, "Failed to fetch roles", err)
 		}
 	}
 
@@ -162,39 +277,39 @@ func (b *Bot) handleRestore(s *discordgo.Session, i *discordgo.InteractionCreate
 	isolationRoleID, err := b.pm.GetIsolationRoleID(i.GuildID)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return utils.CreateNotAllowedEmbed("Isolation role not set.", "Please set it using /setisolationrole.")
+			return nil, utils.CreateNotAllowedEmbed("Isolation role not set.", "Please set it using /setisolationrole.")
 		}
 		log.Printf("Error fetching isolation role: %v", err)
-		return utils.CreateErrorEmbed(s, i, "Failed to fetch isolation role", err)
+		return nil, utils.CreateErrorEmbed(s, i, "Failed to fetch isolation role", err)
 	}
 
 	// Ensure the person issuing the command has a role that is higher than the target's highest
 	issuer, err := s.GuildMember(i.GuildID, i.Member.User.ID)
 	if err != nil {
 		log.Printf("Error fetching issuer member: %v", err)
-		return utils.CreateErrorEmbed(s, i, "Failed to fetch issuer member", err)
+		return nil, utils.CreateErrorEmbed(s, i, "Failed to fetch issuer member", err)
 	}
 
 	guild, err := s.Guild(i.GuildID)
 	if err != nil {
 		log.Printf("Error fetching guild: %v", err)
-		return utils.CreateErrorEmbed(s, i, "Failed to fetch guild", err)
+		return nil, utils.CreateErrorEmbed(s, i, "Failed to fetch guild", err)
 	}
 
 	member, err := s.GuildMember(i.GuildID, user.ID)
 	if err != nil {
 		if utils.CheckError(err, discordgo.ErrCodeUnknownMember) {
-			return utils.CreateNotAllowedEmbed("User not found", "The user you are trying to restore is not in this server.")
+			return nil, utils.CreateNotAllowedEmbed("User not found", "The user you are trying to restore is not in this server.")
 		}
 		log.Printf("Error fetching member: %v", err)
-		return utils.CreateErrorEmbed(s, i, "Failed to fetch member", err)
+		return nil, utils.CreateErrorEmbed(s, i, "Failed to fetch member", err)
 	}
 
 	issuerHighestRole := utils.GetHighestRole(issuer.Roles, guild.Roles)
 	targetHighestRole := utils.GetHighestRole(member.Roles, guild.Roles)
 
 	if issuerHighestRole == nil || (targetHighestRole != nil && issuerHighestRole.Position <= targetHighestRole.Position) {
-		return utils.CreateErrorEmbed(s, i, "You don't have permission to isolate this user. Your highest role must be higher than the target user's highest role.", err)
+		return nil, utils.CreateErrorEmbed(s, i, "You don't have permission to isolate this user. Your highest role must be higher than the target user's highest role.", err)
 	}
 
 	// Check if user is isolated
@@ -203,11 +318,26 @@ func (b *Bot) handleRestore(s *discordgo.Session, i *discordgo.InteractionCreate
 			goto pass
 		}
 	}
-	return utils.CreateErrorEmbed(s, i, fmt.Sprintf("User %s is not isolated.", user.Mention()), err)
+	return nil, utils.CreateErrorEmbed(s, i, fmt.Sprintf("User %s is not isolated.", user.Mention()), err)
 
 pass:
+	messages = append(messages, b.restoreRoles(s, i.GuildID, user, isolationRoleID, roleIDs)...)
+	b.forgetIsolation(i.GuildID, user.ID)
+
+	// Record it in the mod log
+	if _, errEmbed := b.logAction(s, i, user, actionRestore, reason, nil); errEmbed != nil {
+		messages.AddMessage(fmt.Sprintf("Could not log the restore: %v", errEmbed.Description))
+	}
+	return messages, nil
+}
+
+// restoreRoles swaps the isolation role for the user's saved roles, returning what was done.
+// roleIDs is as saved in user_roles.
+func (b *Bot) restoreRoles(s *discordgo.Session, guildID string, user *discordgo.User, isolationRoleID, roleIDs string) utils.Messages {
+	messages := utils.Messages{}
+
 	// Remove isolation role
-	err = s.GuildMemberRoleRemove(i.GuildID, user.ID, isolationRoleID)
+	err := s.GuildMemberRoleRemove(guildID, user.ID, isolationRoleID)
 	if err != nil {
 		log.Printf("Error removing isolation role: %v", err)
 		messages.AddMessage("Failed to remove isolation role")
@@ -219,7 +349,7 @@ pass:
 			if roleID == "" {
 				continue
 			}
-			err = s.GuildMemberRoleAdd(i.GuildID, user.ID, roleID)
+			err = s.GuildMemberRoleAdd(guildID, user.ID, roleID)
 			if err != nil {
 				log.Printf("Error adding role: %v", err)
 				messages.AddMessage(fmt.Sprintf("Failed to add role %s: %v", roleID, err.Error()))
@@ -227,7 +357,7 @@ pass:
 			}
 			// Fetch the roles
 			rolemsg := fmt.Sprintf("`%v`", roleID)
-			role, err := s.State.Role(i.GuildID, roleID)
+			role, err := s.State.Role(guildID, roleID)
 			if err != nil {
 				log.Printf("Error fetching role: %v", err)
 				err = nil
@@ -239,11 +369,106 @@ pass:
 		// Log the restored roles
 		log.Printf("Restored roles for user %s: %s", user.Username, roleIDs)
 	}
+	return messages
+}
 
+// forgetIsolation removes everything kept about a user's isolation once they're restored
+func (b *Bot) forgetIsolation(guildID, userID string) {
 	// Delete the user's roles from the database
-	_, err = b.db.Exec("DELETE FROM user_roles WHERE user_id = ? AND guild_id = ?", user.ID, i.GuildID)
+	_, err := b.db.Exec("DELETE FROM user_roles WHERE user_id = ? AND guild_id = ?", userID, guildID)
 	if err != nil {
 		log.Printf("Error deleting roles: %v", err)
 	}
-	return utils.CreateEmbed(fmt.Sprintf("User %s (`%v`) has been restored.", user.Username, user.ID), messages.GetMessages(""))
+	// Any pending appeal is no longer needed
+	_, err = b.db.Exec("DELETE FROM appeals WHERE user_id = ? AND guild_id = ?", userID, guildID)
+	if err != nil {
+		log.Printf("Error deleting appeal: %v", err)
+	}
+	err = b.cm.RemoveTempIsolation(guildID, userID)
+	if err != nil {
+		log.Printf("Error removing temporary isolation: %v", err)
+	}
+}
+
+// scheduleRestore records when a temporary isolation should be lifted
+func (b *Bot) scheduleRestore(guildID string, user *discordgo.User, duration time.Duration, c *cases.Case) utils.Messages {
+	messages := utils.Messages{}
+	t := cases.TempIsolation{
+		GuildID:   guildID,
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(duration),
+	}
+	if c != nil {
+		t.CaseNumber = c.Number
+	}
+	err := b.cm.AddTempIsolation(t)
+	if err != nil {
+		log.Printf("Error saving temporary isolation: %v", err)
+		messages.AddMessage("**Could not schedule the restore.** Please restore the user manually when the time is up.")
+		return messages
+	}
+	messages.AddMessage(fmt.Sprintf("%v will be restored <t:%v:R>.", user.Mention(), t.ExpiresAt.Unix()))
+	return messages
+}
+
+// liftExpiredIsolations restores users whose temporary isolation expired, logging it as the bot
+func (b *Bot) liftExpiredIsolations() {
+	s := b.Session
+	expired, err := b.cm.ExpiredTempIsolations(time.Now())
+	if err != nil {
+		log.Printf("Error fetching expired isolations: %v", err)
+		return
+	}
+
+	for _, t := range expired {
+		var roleIDs string
+		err := b.db.QueryRow("SELECT roles FROM user_roles WHERE user_id = ? AND guild_id = ?", t.UserID, t.GuildID).Scan(&roleIDs)
+		if err == sql.ErrNoRows {
+			// Already restored by hand
+			b.forgetIsolation(t.GuildID, t.UserID)
+			continue
+		}
+		if err != nil {
+			log.Printf("Error fetching roles: %v", err)
+			continue
+		}
+		isolationRoleID, err := b.pm.GetIsolationRoleID(t.GuildID)
+		if err != nil {
+			log.Printf("Error fetching isolation role: %v", err)
+			continue
+		}
+		user, err := s.User(t.UserID)
+		if err != nil {
+			// Try again next time
+			log.Printf("Error fetching user: %v", err)
+			continue
+		}
+
+		// Members who left are restored by forgetting their isolation, so it isn't applied again when they rejoin
+		reason := "Temporary isolation expired"
+		_, err = s.GuildMember(t.GuildID, t.UserID)
+		if err == nil {
+			for _, message := range b.restoreRoles(s, t.GuildID, user, isolationRoleID, roleIDs) {
+				log.Printf("Lifting isolation of %v in %v: %v", t.UserID, t.GuildID, message)
+			}
+		} else if !utils.CheckError(err, discordgo.ErrCodeUnknownMember) {
+			log.Printf("Error fetching member: %v", err)
+			continue
+		}
+		b.forgetIsolation(t.GuildID, t.UserID)
+
+		c := &cases.Case{
+			GuildID:     t.GuildID,
+			ModeratorID: s.State.User.ID,
+			TargetID:    t.UserID,
+			Action:      actionRestore,
+			Reason:      reason,
+			CreatedAt:   time.Now(),
+			LinkedCase:  t.CaseNumber,
+		}
+		err = b.recordCase(s, c, nil)
+		if err != nil {
+			log.Printf("Error logging expired isolation: %v", err)
+		}
+	}
 }
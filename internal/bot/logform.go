@@ -0,0 +1,156 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/shininglegend/shieldbot/internal/cases"
+	"github.com/shininglegend/shieldbot/pkg/auth"
+	"github.com/shininglegend/shieldbot/pkg/utils"
+)
+
+// Custom ID of the text inputs in the log form
+const (
+	inputLogReason  = "reason"
+	inputLogDetails = "details"
+	inputLogLinks   = "links"
+)
+
+// handleLogFormOpen answers /log form:True with a modal for a longer reason, details and evidence links.
+// The other options are carried over in the modal's custom ID.
+func (b *Bot) handleLogFormOpen(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if e := auth.QuickAuthManageMessagesOrOverride(b.pm, s, i); e != nil {
+		respondEphemeral(s, i, e)
+		return
+	}
+	options := i.ApplicationCommandData().Options
+	user := options[0].UserValue(s)
+	action := options[1].StringValue()
+
+	if len(evidenceAttachments(i)) > 0 {
+		respondEphemeral(s, i, utils.CreateNotAllowedEmbed("Files can't be used with the form", "Add links to the evidence in the form, or log without the form to upload files."))
+		return
+	}
+
+	execute := "0"
+	if opt := utils.FindOption(options, "execute"); opt != nil && opt.BoolValue() {
+		execute = "1"
+	}
+	duration := ""
+	if opt := utils.FindOption(options, "duration"); opt != nil {
+		duration = strings.TrimSpace(opt.StringValue())
+		// Check it now, rather than after the moderator wrote everything out
+		if _, err := utils.ParseDuration(duration); err != nil {
+			respondEphemeral(s, i, utils.CreateNotAllowedEmbed("Invalid duration", "Use a duration like `30m`, `12h`, `3d` or `2w`."))
+			return
+		}
+	}
+	b.openLogForm(s, i, user, action, execute, duration, optionalReason(options))
+}
+
+// openLogForm answers an interaction with the log form. execute is "0" or "1", and the duration may be empty.
+func (b *Bot) openLogForm(s *discordgo.Session, i *discordgo.InteractionCreate, user *discordgo.User, action, execute, duration, reason string) {
+	customID := makeCustomID(compLogForm, user.ID, action, execute, duration)
+	if len(customID) > 100 {
+		respondEphemeral(s, i, utils.CreateNotAllowedEmbed("Invalid duration", "That duration is too long to carry over to the form."))
+		return
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID: customID,
+			Title:    truncate(fmt.Sprintf("Log %v: %v", action, user.Username), 45),
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					discordgo.TextInput{
+						CustomID:  inputLogReason,
+						Label:     "Reason",
+						Style:     discordgo.TextInputShort,
+						Value:     reason,
+						Required:  true,
+						MaxLength: 512,
+					},
+				}},
+				discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					discordgo.TextInput{
+						CustomID:  inputLogDetails,
+						Label:     "Additional details",
+						Style:     discordgo.TextInputParagraph,
+						Required:  false,
+						MaxLength: 1024,
+					},
+				}},
+				discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					discordgo.TextInput{
+						CustomID:    inputLogLinks,
+						Label:       "Evidence links",
+						Style:       discordgo.TextInputParagraph,
+						Placeholder: "One link per line, e.g. message links or screenshots",
+						Required:    false,
+						MaxLength:   1000,
+					},
+				}},
+			},
+		},
+	})
+	if err != nil {
+		log.Printf("Error opening log form: %v", err)
+	}
+}
+
+// handleLogFormSubmit logs the action from a submitted log form
+func (b *Bot) handleLogFormSubmit(s *discordgo.Session, i *discordgo.InteractionCreate, args []string) {
+	// The custom ID comes from the client, so don't trust that the form was opened through /log.
+	// Executing the action is authorized separately by executeAction.
+	if e := auth.QuickAuthManageMessagesOrOverrideFor(b.pm, s, i, cmdLogging); e != nil {
+		respondEphemeral(s, i, e)
+		return
+	}
+	// Logging takes a few requests, so acknowledge first
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		log.Printf("Error acknowledging interaction: %v", err)
+		return
+	}
+
+	userID, action, execute, duration := args[0], args[1], args[2] == "1", args[3]
+	var embed *discordgo.MessageEmbed
+	user, err := s.User(userID)
+	if err != nil {
+		embed = utils.CreateErrorEmbed(s, i, "Failed to fetch user", err)
+	} else if links, errEmbed := parseEvidenceLinks(modalValue(i, inputLogLinks)); errEmbed != nil {
+		embed = errEmbed
+	} else {
+		evidence := &caseEvidence{
+			links:   links,
+			details: strings.TrimSpace(modalValue(i, inputLogDetails)),
+		}
+		embed = b.executeAndLog(s, i, user, action, strings.TrimSpace(modalValue(i, inputLogReason)), execute, duration, evidence)
+	}
+	b.editResponseEmbed(s, i, true, embed, nil, nil)
+}
+
+// parseEvidenceLinks reads one link per line from the log form
+func parseEvidenceLinks(input string) ([]cases.Attachment, *discordgo.MessageEmbed) {
+	var links []cases.Attachment
+	for _, line := range strings.Fields(input) {
+		u, err := url.Parse(line)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, utils.CreateNotAllowedEmbed("Invalid evidence link", fmt.Sprintf("`%v` is not a link. Nothing was logged.", truncate(line, 100)))
+		}
+		links = append(links, cases.Attachment{
+			Filename: u.Host + u.Path,
+			URL:      line,
+		})
+	}
+	return links, nil
+}
@@ -0,0 +1,113 @@
+// This file posts the bot's errors and AutoMod hits to their log channels
+package bot
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/shininglegend/shieldbot/internal/logging"
+	"github.com/shininglegend/shieldbot/pkg/utils"
+)
+
+// logError posts an error from a command to the guild's error log, so staff can see what went wrong
+func (b *Bot) logError(s *discordgo.Session, i *discordgo.InteractionCreate, desc string, err error) {
+	if i.GuildID == "" {
+		return
+	}
+	embed := &discordgo.MessageEmbed{
+		Title:       "Bot Error",
+		Description: desc,
+		Color:       0xFF0000, // Red
+		Timestamp:   time.Now().Format(time.RFC3339),
+		Fields: []*discordgo.MessageEmbedField{
+			{
+				Name:  "Error",
+				Value: truncate(fmt.Sprintf("`%v`", err), 1024),
+			},
+			{
+				Name:   "User",
+				Value:  utils.SafeUser(i.Interaction).Mention(),
+				Inline: true,
+			},
+			{
+				Name:   "Channel",
+				Value:  fmt.Sprintf("<#%v>", i.ChannelID),
+				Inline: true,
+			},
+		},
+	}
+	if i.Type == discordgo.InteractionApplicationCommand {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   "Command",
+			Value:  "/" + i.ApplicationCommandData().Name,
+			Inline: true,
+		})
+	}
+	sendErr := b.logger.SendEmbed(s, i.GuildID, logging.CategoryErrors, embed)
+	if sendErr != nil && sendErr != sql.ErrNoRows {
+		log.Printf("Error posting error to the log channel: %v", sendErr)
+	}
+}
+
+// handleAutoModAction logs each action Discord's AutoMod takes
+func (b *Bot) handleAutoModAction(s *discordgo.Session, e *discordgo.AutoModerationActionExecution) {
+	action := map[discordgo.AutoModerationActionType]string{
+		discordgo.AutoModerationRuleActionBlockMessage:     "Blocked the message",
+		discordgo.AutoModerationRuleActionSendAlertMessage: "Sent an alert",
+		discordgo.AutoModerationRuleActionTimeout:          "Timed out the member",
+	}[e.Action.Type]
+	if action == "" {
+		action = fmt.Sprintf("Action %v", e.Action.Type)
+	}
+	if e.Action.Type == discordgo.AutoModerationRuleActionTimeout && e.Action.Metadata != nil {
+		action = fmt.Sprintf("%v for %v", action, time.Duration(e.Action.Metadata.Duration)*time.Second)
+	}
+
+	ruleName := e.RuleID
+	if rule, err := s.AutoModerationRule(e.GuildID, e.RuleID); err == nil {
+		ruleName = rule.Name
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "AutoMod Hit",
+		Description: fmt.Sprintf("<@%v> `%v` triggered **%v**", e.UserID, e.UserID, ruleName),
+		Color:       0xFFA500, // Orange
+		Timestamp:   time.Now().Format(time.RFC3339),
+		Fields: []*discordgo.MessageEmbedField{
+			{
+				Name:   "Action",
+				Value:  action,
+				Inline: true,
+			},
+		},
+	}
+	if e.ChannelID != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   "Channel",
+			Value:  fmt.Sprintf("<#%v>", e.ChannelID),
+			Inline: true,
+		})
+	}
+	if e.MatchedKeyword != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   "Keyword",
+			Value:  truncate(e.MatchedKeyword, 1024),
+			Inline: true,
+		})
+	}
+	// Content needs the message content intent, so it may be empty
+	if e.Content != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  "Content",
+			Value: truncate(e.Content, 1024),
+		})
+	}
+
+	err := b.logger.SendEmbed(s, e.GuildID, logging.CategoryAutomod, embed)
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("Error logging AutoMod action: %v", err)
+	}
+}
@@ -1,10 +1,16 @@
 package bot
 
 import (
+	"database/sql"
 	"fmt"
+	"log"
+	"strings"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
+	"github.com/shininglegend/shieldbot/internal/cases"
+	"github.com/shininglegend/shieldbot/internal/logging"
+	"github.com/shininglegend/shieldbot/pkg/auth"
 	"github.com/shininglegend/shieldbot/pkg/utils"
 )
 
@@ -17,94 +23,283 @@ const (
 	actionKick       = "kick"
 	actionBan        = "ban"
 	actionOther      = "other"
+
+	// Logged automatically by /restore, /tempban and /unban
+	actionRestore = "restore"
+	actionTempBan = "tempban"
+	actionUnban   = "unban"
 )
 
+// logActions lists every action type in the order they're shown
+var logActions = []string{actionVerbalWarn, actionBotWarn, actionTimeout, actionIsolate, actionKick, actionTempBan, actionBan, actionOther, actionRestore, actionUnban}
+
+// caseEvidence is the optional supporting material of a logged case
+type caseEvidence struct {
+	files   []*discordgo.MessageAttachment // Uploaded with the command, re-uploaded to the mod log
+	links   []cases.Attachment
+	details string
+}
+
 func (b *Bot) handleLogging(s *discordgo.Session, i *discordgo.InteractionCreate) *discordgo.MessageEmbed {
+	if e := auth.QuickAuthManageMessagesOrOverride(b.pm, s, i); e != nil {
+		return e
+	}
+
 	// Get the user and action from the interaction
-	user := i.ApplicationCommandData().Options[0].UserValue(s)
-	action := i.ApplicationCommandData().Options[1].StringValue()
+	options := i.ApplicationCommandData().Options
+	user := options[0].UserValue(s)
+	action := options[1].StringValue()
 
-	// Log the action
-	errEmd := b.logAction(s, i, user, action)
-	if errEmd != nil {
-		return errEmd
+	execute := false
+	if opt := utils.FindOption(options, "execute"); opt != nil {
+		execute = opt.BoolValue()
 	}
-
-	// Return the response
-	return utils.CreateEmbed("Logged action", fmt.Sprintf("Logged action for %v: %v", user.Mention(), action))
+	duration := ""
+	if opt := utils.FindOption(options, "duration"); opt != nil {
+		duration = opt.StringValue()
+	}
+	return b.executeAndLog(s, i, user, action, optionalReason(options), execute, duration, &caseEvidence{files: evidenceAttachments(i)})
 }
 
-func (b *Bot) handleLoggingExternal(s *discordgo.Session, i *discordgo.InteractionCreate) *discordgo.MessageEmbed {
-	// Get the user and action from the interaction
-	user, err := s.User(i.ApplicationCommandData().Options[0].StringValue())
-	if user == nil || err != nil {
-		embed := utils.CreateEmbed("Error", err.Error())
-		embed.Color = 0xFF0000 // Red
-		embed.Timestamp = time.Now().Format(time.RFC3339)
-		return embed
+// executeAndLog has the bot perform the action if asked to, then logs it and applies any escalation.
+// Shared by /log and its form.
+func (b *Bot) executeAndLog(s *discordgo.Session, i *discordgo.InteractionCreate, user *discordgo.User, action, reason string, execute bool, durationInput string, evidence *caseEvidence) *discordgo.MessageEmbed {
+	// Have the bot perform the action itself first, if asked to
+	messages := utils.Messages{}
+	var duration time.Duration
+	if execute {
+		if durationInput != "" {
+			d, err := utils.ParseDuration(durationInput)
+			if err != nil {
+				return utils.CreateNotAllowedEmbed("Invalid duration", "Use a duration like `30m`, `12h`, `3d` or `2w`.")
+			}
+			duration = d
+		}
+		// A ban with a duration is a temporary ban
+		if action == actionBan && duration > 0 {
+			action = actionTempBan
+		}
+		actionMessages, errEmbed := b.executeAction(s, i, user, action, duration, reason, 0)
+		if errEmbed != nil {
+			return errEmbed
+		}
+		messages = append(messages, actionMessages...)
+		messages.AddMessage(fmt.Sprintf("The bot performed the %v.", action))
 	}
-	action := i.ApplicationCommandData().Options[1].StringValue()
 
 	// Log the action
-	errEmd := b.logAction(s, i, user, action)
+	c, errEmd := b.logAction(s, i, user, action, reason, evidence)
+	// The ban happened either way, so it must still be lifted
+	if action == actionTempBan {
+		messages = append(messages, b.scheduleUnban(i.GuildID, user, duration, c)...)
+	}
 	if errEmd != nil {
+		if execute {
+			errEmd.Description = fmt.Sprintf("The %v was performed, but logging it failed: %v", action, errEmd.Description)
+		}
+		errEmd.Description = messages.GetMessages(errEmd.Description)
 		return errEmd
 	}
 
+	// Apply any escalation the new points trigger
+	messages = append(messages, b.escalate(s, i, user, c)...)
+
 	// Return the response
-	return utils.CreateEmbed("Logged action", fmt.Sprintf("Logged action for %v: %v", user.Mention(), action))
+	return utils.CreateEmbed("Logged action", messages.GetMessages(fmt.Sprintf("Logged action for %v: %v (case #%v)", user.Mention(), action, c.Number)))
 }
 
-func (b *Bot) logAction(s *discordgo.Session, i *discordgo.InteractionCreate, user *discordgo.User, action string) *discordgo.MessageEmbed {
+// optionalReason returns the reason option, or an empty string if it wasn't given
+func optionalReason(options []*discordgo.ApplicationCommandInteractionDataOption) string {
+	if opt := utils.FindOption(options, "reason"); opt != nil {
+		return opt.StringValue()
+	}
+	return ""
+}
+
+// logAction records the action as a case, taken by the member who triggered the interaction, and posts it to the mod log.
+// Evidence is optional, uploaded files are re-uploaded with the log message.
+func (b *Bot) logAction(s *discordgo.Session, i *discordgo.InteractionCreate, user *discordgo.User, action, reason string, evidence *caseEvidence) (*cases.Case, *discordgo.MessageEmbed) {
 	// Get the guild
 	guild, err := s.Guild(i.GuildID)
 	if err != nil {
-		return utils.CreateErrorEmbed(s, i, "Error getting guild", err)
+		return nil, utils.CreateErrorEmbed(s, i, "Error getting guild", err)
+	}
+
+	// Invalid actions are never stored. They come from autocomplete, so they may be anything.
+	if _, _, ok := b.actionType(guild.ID, action); !ok {
+		return nil, utils.CreateNotAllowedEmbed("Unknown action", fmt.Sprintf("There is no action `%v`. Pick one from the list, or add it with /config actions add.", action))
+	}
+
+	points, err := b.cm.GetActionPoints(guild.ID, action)
+	if err != nil {
+		return nil, utils.CreateErrorEmbed(s, i, "Error getting action points", err)
+	}
+
+	if evidence == nil {
+		evidence = &caseEvidence{}
+	}
+	files, err := downloadEvidence(evidence.files)
+	if err != nil {
+		return nil, utils.CreateNotAllowedEmbed("Failed to attach evidence", fmt.Sprintf("Nothing was logged. %v", err))
 	}
 
-	// Get the mod log channel
-	modLogChannelID, err := b.pm.GetLogChannelID(guild.ID)
+	c := &cases.Case{
+		GuildID:     guild.ID,
+		ModeratorID: i.Member.User.ID,
+		TargetID:    user.ID,
+		Action:      action,
+		Reason:      reason,
+		CreatedAt:   time.Now(),
+		Points:      points,
+		Details:     evidence.details,
+		Attachments: evidence.links,
+	}
+	err = b.recordCase(s, c, files)
 	if err != nil {
-		return utils.CreateErrorEmbed(s, i, "Error getting mod log channel", err)
+		if err == sql.ErrNoRows {
+			return nil, utils.CreateNotAllowedEmbed("Log channel not set.", "Please set it using /config setlogchannel.")
+		}
+		return nil, utils.CreateErrorEmbed(s, i, "Error logging action", err)
 	}
+	return c, nil
+}
 
-	// Set color based on action
-	var color int
+// recordCase stores the case and posts it to the guild's mod log channel, with any files attached.
+// Returns sql.ErrNoRows if the guild has no log channel.
+func (b *Bot) recordCase(s *discordgo.Session, c *cases.Case, files []*discordgo.File) error {
+	// Make sure there's somewhere to post it
+	_, err := b.logger.ChannelID(c.GuildID, logging.CategoryModeration)
+	if err != nil {
+		return err
+	}
+
+	// The case number is needed for the embed, so store it first
+	err = b.cm.CreateCase(c)
+	if err != nil {
+		return fmt.Errorf("error saving case: %w", err)
+	}
+
+	msg, err := b.sendUserLog(s, c.GuildID, c.TargetID, &discordgo.MessageSend{
+		Embed: b.caseEmbed(c),
+		Files: files,
+	})
+	if err != nil {
+		// Don't keep cases nobody can see
+		if delErr := b.cm.DeleteCase(c.GuildID, c.Number); delErr != nil {
+			log.Printf("Error deleting unsent case: %v", delErr)
+		}
+		return fmt.Errorf("error sending mod log message: %w", err)
+	}
+
+	c.LogChannelID, c.LogMessageID = msg.ChannelID, msg.ID
+	err = b.cm.SetLogMessage(c.GuildID, c.Number, msg.ChannelID, msg.ID)
+	if err != nil {
+		log.Printf("Error saving log message for case %v: %v", c.Number, err)
+	}
+
+	// Keep track of the evidence, uploads where they now live
+	c.Attachments = append(c.Attachments, caseAttachments(msg)...)
+	if len(c.Attachments) > 0 {
+		err = b.cm.AddAttachments(c.GuildID, c.Number, c.Attachments)
+		if err != nil {
+			log.Printf("Error saving attachments for case %v: %v", c.Number, err)
+		}
+	}
+
+	err = b.openCaseThread(s, c, msg)
+	if err != nil {
+		log.Printf("Error opening thread for case %v: %v", c.Number, err)
+	}
+	return nil
+}
+
+// openCaseThread starts a discussion thread on the log message of a case, if the guild wants one
+func (b *Bot) openCaseThread(s *discordgo.Session, c *cases.Case, msg *discordgo.Message) error {
+	enabled, archiveMinutes, err := b.pm.GetCaseThreads(c.GuildID)
+	if err != nil || !enabled {
+		return err
+	}
+	// Messages in a forum post are already in a thread
+	channel, err := fetchChannel(s, msg.ChannelID)
+	if err != nil {
+		return err
+	}
+	if channel.IsThread() {
+		return nil
+	}
+
+	label, _, _ := b.actionType(c.GuildID, c.Action)
+	thread, err := s.MessageThreadStartComplex(msg.ChannelID, msg.ID, &discordgo.ThreadStart{
+		Name:                truncate(fmt.Sprintf("Case #%v - %v", c.Number, label), 100),
+		AutoArchiveDuration: archiveMinutes,
+	})
+	if err != nil {
+		return err
+	}
+	c.ThreadID = thread.ID
+	return b.cm.SetThread(c.GuildID, c.Number, thread.ID)
+}
+
+// actionType returns the label and color of a built-in or custom action, and whether the guild has it
+func (b *Bot) actionType(guildID, action string) (string, int, bool) {
+	if color, ok := actionColor(action); ok {
+		return action, color, true
+	}
+	a, err := b.cm.GetActionType(guildID, action)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("Error fetching action type: %v", err)
+		}
+		return action, 0, false
+	}
+	return a.Label, a.Color, true
+}
+
+// actionColor returns the embed color for a built-in action, and whether the action is built in
+func actionColor(action string) (int, bool) {
 	switch action {
 	case actionVerbalWarn:
-		color = 0xFFFF00 // Yellow
+		return 0xFFFF00, true // Yellow
 	case actionBotWarn:
-		color = 0xFFFF00 // Yellow
+		return 0xFFFF00, true // Yellow
 	case actionTimeout:
-		color = 0xFFA500 // Orange
+		return 0xFFA500, true // Orange
 	case actionIsolate:
-		color = 0xFFA500 // Orange
+		return 0xFFA500, true // Orange
 	case actionKick:
-		color = 0xFF0000 // Red
-	case actionBan:
-		color = 0xFF0000 // Red
+		return 0xFF0000, true // Red
+	case actionBan, actionTempBan:
+		return 0xFF0000, true // Red
 	case actionOther:
-		color = 0x0000FF // Blue
+		return 0x0000FF, true // Blue
+	case actionRestore, actionUnban:
+		return 0x00FF00, true // Green
 	default:
-		// Invalid action
-		return utils.CreateErrorEmbed(s, i, "Invalid action", fmt.Errorf("invalid action: %v", action))
+		return 0, false
+	}
+}
+
+// caseEmbed builds the mod log embed for a case
+func (b *Bot) caseEmbed(c *cases.Case) *discordgo.MessageEmbed {
+	label, color, ok := b.actionType(c.GuildID, c.Action)
+	if !ok {
+		color = 0x808080 // Grey, for custom actions that were since removed
 	}
 	// Set the default reason
-	reason := "*Reason not provided, and should be included below.*" // Default reason
-	if len(i.ApplicationCommandData().Options) > 2 {
-		reason = i.ApplicationCommandData().Options[2].StringValue()
+	reason := c.Reason
+	if reason == "" {
+		reason = "*Reason not provided, and should be included below.*"
 	}
 
-	// Create the embed
 	embed := &discordgo.MessageEmbed{
-		Title:       "Moderator Action Log",
-		Description: fmt.Sprintf("Moderator %v took action on %v `%v`", i.Member.User.Mention(), user.Mention(), user.ID),
-		Timestamp:   time.Now().Format(time.RFC3339),
+		Title:       fmt.Sprintf("Moderator Action Log | Case #%v", c.Number),
+		Description: fmt.Sprintf("Moderator <@%v> took action on <@%v> `%v`", c.ModeratorID, c.TargetID, c.TargetID),
+		Timestamp:   c.CreatedAt.Format(time.RFC3339),
 		Color:       color,
 		Fields: []*discordgo.MessageEmbedField{
 			{
 				Name:   "Action",
-				Value:  action,
+				Value:  label,
 				Inline: true,
 			},
 			{
@@ -117,14 +312,48 @@ func (b *Bot) logAction(s *discordgo.Session, i *discordgo.InteractionCreate, us
 			Text: "Further details and file attachments may be added below",
 		},
 	}
-
-	// Send the embed, with the id in the message to make it easier to find
-	_, err = s.ChannelMessageSendComplex(modLogChannelID, &discordgo.MessageSend{
-		Content: fmt.Sprintf("User ID: %v", user.ID),
-		Embed:   embed,
-	})
-	if err != nil {
-		return utils.CreateErrorEmbed(s, i, "Error sending mod log message", err)
+	if c.Points > 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   "Points",
+			Value:  fmt.Sprintf("%v", c.Points),
+			Inline: true,
+		})
 	}
-	return nil
+	if c.Details != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  "Details",
+			Value: truncate(c.Details, 1024),
+		})
+	}
+	if len(c.Attachments) > 0 {
+		var links strings.Builder
+		for n, a := range c.Attachments {
+			link := fmt.Sprintf("[%v](%v)\n", truncate(a.Filename, 60), a.URL)
+			// Embed fields are limited to 1024 characters
+			if links.Len()+len(link) > 1000 {
+				links.WriteString(fmt.Sprintf("*...and %v more*", len(c.Attachments)-n))
+				break
+			}
+			links.WriteString(link)
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  "Evidence",
+			Value: links.String(),
+		})
+	}
+	if c.LinkedCase != 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   "Linked Case",
+			Value:  fmt.Sprintf("#%v", c.LinkedCase),
+			Inline: true,
+		})
+	}
+	if c.ThreadID != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   "Discussion",
+			Value:  fmt.Sprintf("<#%v>", c.ThreadID),
+			Inline: true,
+		})
+	}
+	return embed
 }
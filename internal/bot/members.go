@@ -0,0 +1,69 @@
+// This file keeps track of members, so their roles and join date are still known after they leave
+package bot
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// memberSnapshot is what's remembered of a member
+type memberSnapshot struct {
+	joinedAt time.Time
+	roles    []string
+}
+
+// memberTracker remembers members by guild and user ID.
+// discordgo removes a member from its state before leave handlers run, so this keeps a copy.
+type memberTracker struct {
+	mu      sync.Mutex
+	members map[string]memberSnapshot
+}
+
+func newMemberTracker() *memberTracker {
+	return &memberTracker{members: make(map[string]memberSnapshot)}
+}
+
+func (t *memberTracker) add(guildID string, m *discordgo.Member) {
+	if m == nil || m.User == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.members[guildID+":"+m.User.ID] = memberSnapshot{joinedAt: m.JoinedAt, roles: m.Roles}
+}
+
+// take returns and forgets a member, returning false if they weren't known
+func (t *memberTracker) take(guildID, userID string) (memberSnapshot, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	key := guildID + ":" + userID
+	snapshot, ok := t.members[key]
+	delete(t.members, key)
+	return snapshot, ok
+}
+
+// trackGuild remembers the members sent with a guild, and asks for the rest if the guild is too large to send them all
+func (b *Bot) trackGuild(s *discordgo.Session, g *discordgo.GuildCreate) {
+	for _, m := range g.Members {
+		b.members.add(g.ID, m)
+	}
+	if g.Large {
+		err := s.RequestGuildMembers(g.ID, "", 0, "", false)
+		if err != nil {
+			log.Printf("Error requesting guild members: %v", err)
+		}
+	}
+}
+
+func (b *Bot) trackMemberChunk(_ *discordgo.Session, c *discordgo.GuildMembersChunk) {
+	for _, m := range c.Members {
+		b.members.add(c.GuildID, m)
+	}
+}
+
+func (b *Bot) trackMemberUpdate(_ *discordgo.Session, m *discordgo.GuildMemberUpdate) {
+	b.members.add(m.GuildID, m.Member)
+}
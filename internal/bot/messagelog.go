@@ -0,0 +1,241 @@
+// This file logs edited and deleted messages, using the message cache since Discord doesn't send the old content
+package bot
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/shininglegend/shieldbot/internal/logging"
+	"github.com/shininglegend/shieldbot/internal/msgcache"
+	"github.com/shininglegend/shieldbot/pkg/utils"
+)
+
+// cachedMessage keeps what's needed to log a message later
+func cachedMessage(m *discordgo.Message) *msgcache.Message {
+	cached := &msgcache.Message{
+		ID:         m.ID,
+		GuildID:    m.GuildID,
+		ChannelID:  m.ChannelID,
+		AuthorID:   m.Author.ID,
+		AuthorName: m.Author.Username,
+		Content:    m.Content,
+		CreatedAt:  m.Timestamp,
+	}
+	for _, attachment := range m.Attachments {
+		cached.Attachments = append(cached.Attachments, attachment.URL)
+	}
+	return cached
+}
+
+// cacheMessage remembers messages sent by members, which includes keeping bots out of their own logs
+func (b *Bot) cacheMessage(_ *discordgo.Session, m *discordgo.MessageCreate) {
+	if m.GuildID == "" || m.Author == nil || m.Author.Bot {
+		return
+	}
+	err := b.messages.Add(cachedMessage(m.Message))
+	if err != nil {
+		log.Printf("Error caching message: %v", err)
+	}
+}
+
+// handleMessageEdit logs the content of a message before and after it was edited
+func (b *Bot) handleMessageEdit(s *discordgo.Session, m *discordgo.MessageUpdate) {
+	// Updates without an edit are embeds loading
+	if m.GuildID == "" || m.Author == nil || m.Author.Bot || m.EditedTimestamp == nil {
+		return
+	}
+	before, err := b.messages.Get(m.ID)
+	if err != nil {
+		log.Printf("Error reading message cache: %v", err)
+	}
+	after := cachedMessage(m.Message)
+	if before != nil {
+		after.CreatedAt = before.CreatedAt
+		if before.Content == after.Content && len(before.Attachments) == len(after.Attachments) {
+			return
+		}
+	}
+	err = b.messages.Add(after)
+	if err != nil {
+		log.Printf("Error caching message: %v", err)
+	}
+
+	beforeContent := "*Not cached*"
+	if before != nil {
+		beforeContent = quoteContent(before.Content)
+	}
+	embed := &discordgo.MessageEmbed{
+		Title:       "Message Edited",
+		URL:         messageLink(m.GuildID, m.ChannelID, m.ID),
+		Description: fmt.Sprintf("%v `%v` edited a message in <#%v>", m.Author.Mention(), m.Author.ID, m.ChannelID),
+		Color:       0x0000FF, // Blue
+		Timestamp:   time.Now().Format(time.RFC3339),
+		Fields: []*discordgo.MessageEmbedField{
+			{
+				Name:  "Before",
+				Value: beforeContent,
+			},
+			{
+				Name:  "After",
+				Value: quoteContent(after.Content),
+			},
+		},
+	}
+	if before != nil && len(before.Attachments) > len(after.Attachments) {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  "Removed Attachments",
+			Value: attachmentList(removedAttachments(before.Attachments, after.Attachments), removedAttachmentsNote),
+		})
+	}
+
+	err = b.logger.SendEmbed(s, m.GuildID, logging.CategoryMessages, embed)
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("Error logging message edit: %v", err)
+	}
+}
+
+// handleMessageDelete logs a deleted message, if it was cached
+func (b *Bot) handleMessageDelete(s *discordgo.Session, m *discordgo.MessageDelete) {
+	if m.GuildID == "" {
+		return
+	}
+	cached, err := b.messages.Get(m.ID)
+	if err != nil {
+		log.Printf("Error reading message cache: %v", err)
+		return
+	}
+	if cached == nil {
+		return
+	}
+	err = b.messages.Remove(m.ID)
+	if err != nil {
+		log.Printf("Error removing message from cache: %v", err)
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "Message Deleted",
+		Description: fmt.Sprintf("A message by <@%v> `%v` was deleted in <#%v>", cached.AuthorID, cached.AuthorID, cached.ChannelID),
+		Color:       0xFF0000, // Red
+		Timestamp:   time.Now().Format(time.RFC3339),
+		Fields: []*discordgo.MessageEmbedField{
+			{
+				Name:  "Content",
+				Value: quoteContent(cached.Content),
+			},
+			{
+				Name:   "Sent",
+				Value:  fmt.Sprintf("<t:%v:f>", cached.CreatedAt.Unix()),
+				Inline: true,
+			},
+		},
+	}
+	if len(cached.Attachments) > 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  "Attachments",
+			Value: attachmentList(cached.Attachments, deletedAttachmentsNote),
+		})
+	}
+
+	err = b.logger.SendEmbed(s, m.GuildID, logging.CategoryMessages, embed)
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("Error logging message delete: %v", err)
+	}
+}
+
+// handleMessageDeleteBulk logs the cached messages of a bulk delete as a transcript
+func (b *Bot) handleMessageDeleteBulk(s *discordgo.Session, e *discordgo.MessageDeleteBulk) {
+	if e.GuildID == "" {
+		return
+	}
+	var deleted []*msgcache.Message
+	for _, id := range e.Messages {
+		cached, err := b.messages.Get(id)
+		if err != nil {
+			log.Printf("Error reading message cache: %v", err)
+			continue
+		}
+		if cached != nil {
+			deleted = append(deleted, cached)
+		}
+	}
+	// Purges through the bot are already logged, and removed from the cache first
+	if len(deleted) == 0 {
+		return
+	}
+	err := b.messages.Remove(e.Messages...)
+	if err != nil {
+		log.Printf("Error removing messages from cache: %v", err)
+	}
+
+	// Oldest first reads more naturally
+	sort.Slice(deleted, func(a, b int) bool { return deleted[a].CreatedAt.Before(deleted[b].CreatedAt) })
+	var transcript strings.Builder
+	for _, m := range deleted {
+		if len(m.Attachments) > 0 {
+			transcript.WriteString(fmt.Sprintf("Note: %v\n\n", deletedAttachmentsNote))
+			break
+		}
+	}
+	for _, m := range deleted {
+		transcript.WriteString(fmt.Sprintf("[%v] %v (%v): %v\n", m.CreatedAt.UTC().Format("2006-01-02 15:04"), m.AuthorName, m.AuthorID, m.Content))
+		for _, url := range m.Attachments {
+			transcript.WriteString(fmt.Sprintf("  Attachment: %v\n", url))
+		}
+	}
+
+	_, err = b.logger.Send(s, e.GuildID, logging.CategoryMessages, &discordgo.MessageSend{
+		Embed: &discordgo.MessageEmbed{
+			Title:       "Messages Bulk Deleted",
+			Description: fmt.Sprintf("%v message(s) were deleted in <#%v>, %v of them were cached", len(e.Messages), e.ChannelID, len(deleted)),
+			Color:       0xFF0000, // Red
+			Timestamp:   time.Now().Format(time.RFC3339),
+		},
+		Files: []*discordgo.File{
+			{
+				Name:        fmt.Sprintf("deleted-%v.txt", e.ChannelID),
+				ContentType: "text/plain",
+				Reader:      strings.NewReader(transcript.String()),
+			},
+		},
+	})
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("Error logging bulk delete: %v", err)
+	}
+}
+
+// quoteContent formats message content for an embed field
+func quoteContent(content string) string {
+	if content == "" {
+		return "*No text*"
+	}
+	return truncate(content, 1024)
+}
+
+// Warnings that removed attachments can't be opened from the log.
+// Discord deletes the files along with the message, or once they're edited out, so there's nothing left to download and re-upload.
+const (
+	deletedAttachmentsNote = "Discord deletes these files with the message, so the links may no longer work."
+	removedAttachmentsNote = "Discord deletes files removed from a message, so the links may no longer work."
+)
+
+// attachmentList lists attachment URLs for an embed field, followed by a note on why they may not open
+func attachmentList(urls []string, note string) string {
+	note = "\n*" + note + "*"
+	return truncate(strings.Join(urls, "\n"), 1024-len(note)) + note
+}
+
+// removedAttachments returns the attachments in before that aren't in after
+func removedAttachments(before, after []string) []string {
+	var removed []string
+	for _, url := range before {
+		if !utils.Contains(after, url) {
+			removed = append(removed, url)
+		}
+	}
+	return removed
+}
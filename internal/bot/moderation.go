@@ -0,0 +1,362 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/shininglegend/shieldbot/internal/cases"
+	"github.com/shininglegend/shieldbot/pkg/auth"
+	"github.com/shininglegend/shieldbot/pkg/utils"
+)
+
+// How often expired temporary bans and isolations are lifted
+const expiryInterval = time.Minute
+
+// handleModeration runs /timeout, /kick, /ban and /tempban: perform the action, then log it
+func (b *Bot) handleModeration(s *discordgo.Session, i *discordgo.InteractionCreate, action string) *discordgo.MessageEmbed {
+	options := i.ApplicationCommandData().Options
+	user := options[0].UserValue(s)
+	reason := optionalReason(options)
+
+	var duration time.Duration
+	if opt := utils.FindOption(options, "duration"); opt != nil {
+		d, err := utils.ParseDuration(opt.StringValue())
+		if err != nil {
+			return utils.CreateNotAllowedEmbed("Invalid duration", "Use a duration like `30m`, `12h`, `3d` or `2w`.")
+		}
+		duration = d
+	}
+	deleteDays := 0
+	if opt := utils.FindOption(options, "delete_days"); opt != nil {
+		deleteDays = int(opt.IntValue())
+	}
+
+	messages, errEmbed := b.executeAction(s, i, user, action, duration, reason, deleteDays)
+	if errEmbed != nil {
+		return errEmbed
+	}
+
+	c, errEmbed := b.logAction(s, i, user, action, reason, nil)
+	if errEmbed != nil {
+		messages.AddMessage(fmt.Sprintf("Could not log the %v: %v", action, errEmbed.Description))
+	}
+	if action == actionTempBan {
+		messages = append(messages, b.scheduleUnban(i.GuildID, user, duration, c)...)
+	}
+	// Timed out members stay around, so their points still matter
+	if action == actionTimeout && c != nil {
+		messages = append(messages, b.escalate(s, i, user, c)...)
+	}
+
+	title := fmt.Sprintf("User %s (`%v`): %v", user.Username, user.ID, action)
+	if duration > 0 {
+		title = fmt.Sprintf("%v for %v", title, utils.FormatDuration(duration))
+	}
+	return utils.CreateEmbed(title, messages.GetMessages(reasonOrDefault(reason)))
+}
+
+func (b *Bot) handleUnban(s *discordgo.Session, i *discordgo.InteractionCreate) *discordgo.MessageEmbed {
+	if e := auth.QuickAuthBanMembersOrOverride(b.pm, s, i); e != nil {
+		return e
+	}
+	options := i.ApplicationCommandData().Options
+	user := options[0].UserValue(s)
+	reason := optionalReason(options)
+	messages := utils.Messages{}
+
+	b.tracker.expect(i.GuildID, user.ID, actionUnban)
+	err := s.GuildBanDelete(i.GuildID, user.ID, discordgo.WithAuditLogReason(fmt.Sprintf("By %v: %v", i.Member.User.Username, reason)))
+	if err != nil {
+		b.tracker.release(i.GuildID, user.ID, actionUnban)
+		if utils.CheckError(err, discordgo.ErrCodeUnknownBan) {
+			return utils.CreateNotAllowedEmbed("Not banned", fmt.Sprintf("%v is not banned from this server.", user.Mention()))
+		}
+		log.Printf("Error unbanning: %v", err)
+		return utils.CreateNotAllowedEmbed(fmt.Sprintf("Failed to unban %v", user.Username), fmt.Sprintf("Make sure the bot has the Ban Members permission.\nError: %v", err))
+	}
+
+	// A manual unban replaces any scheduled one
+	err = b.cm.RemoveTempBan(i.GuildID, user.ID)
+	if err != nil {
+		log.Printf("Error removing temporary ban: %v", err)
+	}
+
+	if _, errEmbed := b.logAction(s, i, user, actionUnban, reason, nil); errEmbed != nil {
+		messages.AddMessage(fmt.Sprintf("Could not log the unban: %v", errEmbed.Description))
+	}
+	return utils.CreateEmbed(fmt.Sprintf("User %s (`%v`) has been unbanned.", user.Username, user.ID), messages.GetMessages(reasonOrDefault(reason)))
+}
+
+// executeAction performs a timeout, kick, ban or temporary ban on behalf of the member who triggered the interaction,
+// after checking their permissions and role hierarchy, and DMs the user about it.
+// Returns an embed only if the action wasn't performed.
+func (b *Bot) executeAction(s *discordgo.Session, i *discordgo.InteractionCreate, user *discordgo.User, action string, duration time.Duration, reason string, deleteDays int) (utils.Messages, *discordgo.MessageEmbed) {
+	messages := utils.Messages{}
+
+	// Authorize the specific action
+	switch action {
+	case actionTimeout, actionKick, actionBan, actionTempBan:
+		if e := b.authorizeAction(s, i, action); e != nil {
+			return nil, e
+		}
+	case actionIsolate:
+		return nil, utils.CreateNotAllowedEmbed("Use /isolate", "Isolations can't be executed through /log, use /isolate instead. It logs the action for you.")
+	default:
+		return nil, utils.CreateNotAllowedEmbed("Can't execute that", "Only timeouts, kicks and bans can be executed by the bot.")
+	}
+
+	// Check durations before anything happens
+	switch action {
+	case actionTimeout:
+		if duration <= 0 {
+			return nil, utils.CreateNotAllowedEmbed("Duration required", "Timeouts need a duration, like `10m`, `1h` or `3d`.")
+		}
+		if duration > maxTimeout {
+			return nil, utils.CreateNotAllowedEmbed("Duration too long", "Discord only allows timeouts of up to 28 days.")
+		}
+	case actionTempBan:
+		if duration <= 0 {
+			return nil, utils.CreateNotAllowedEmbed("Duration required", "Temporary bans need a duration, like `12h`, `3d` or `2w`.")
+		}
+	}
+
+	if user.ID == s.State.User.ID {
+		return nil, utils.CreateNotAllowedEmbed("Hey! I was being a nice bot :(", "I'm not going to do that to myself.")
+	}
+
+	guild, err := s.Guild(i.GuildID)
+	if err != nil {
+		log.Printf("Error fetching guild: %v", err)
+		return nil, utils.CreateErrorEmbed(s, i, "Failed to fetch guild", err)
+	}
+
+	// Bans also work on users outside the server, everything else needs a member
+	member, err := s.GuildMember(i.GuildID, user.ID)
+	if err != nil {
+		if !utils.CheckError(err, discordgo.ErrCodeUnknownMember) {
+			log.Printf("Error fetching member: %v", err)
+			return nil, utils.CreateErrorEmbed(s, i, "Failed to fetch member", err)
+		}
+		if action != actionBan && action != actionTempBan {
+			return nil, utils.CreateNotAllowedEmbed("User not found", fmt.Sprintf("%v is not in this server.", user.Mention()))
+		}
+		member = nil
+	}
+	if member != nil {
+		if e := checkHierarchy(s, i, guild, member, action); e != nil {
+			return nil, e
+		}
+	}
+
+	// Once they're kicked or banned we may no longer share a server, so DM first, and take it back if the action fails
+	var dm *discordgo.Message
+	if member != nil && action != actionTimeout {
+		var note string
+		note, dm = b.notifyModeratedUser(s, guild, user, action, reason, duration)
+		messages.AddMessage(note)
+	}
+
+	// Logged by the caller, not as a native action. Expected before the request, since its events can beat the response.
+	b.tracker.expect(i.GuildID, user.ID, action)
+	auditReason := discordgo.WithAuditLogReason(fmt.Sprintf("By %v: %v", i.Member.User.Username, reasonOrDefault(reason)))
+	switch action {
+	case actionTimeout:
+		until := time.Now().Add(duration)
+		err = s.GuildMemberTimeout(i.GuildID, user.ID, &until, auditReason)
+	case actionKick:
+		err = s.GuildMemberDelete(i.GuildID, user.ID, auditReason)
+	case actionBan, actionTempBan:
+		err = s.GuildBanCreate(i.GuildID, user.ID, deleteDays, auditReason)
+	}
+	if err != nil {
+		log.Printf("Error executing %v: %v", action, err)
+		b.tracker.release(i.GuildID, user.ID, action)
+		if dm != nil {
+			b.retractModerationDM(s, guild, dm, action)
+		}
+		embed := utils.CreateNotAllowedEmbed(fmt.Sprintf("Failed to %v %v", action, user.Username), "Nothing was logged. Make sure the bot has the needed permission and a role above the user's.")
+		embed.Color = 0xFF0000 // Red
+		embed.Fields = []*discordgo.MessageEmbedField{{Name: "Error", Value: err.Error()}}
+		return nil, embed
+	}
+
+	// A permanent ban replaces any scheduled unban
+	if action == actionBan {
+		err = b.cm.RemoveTempBan(i.GuildID, user.ID)
+		if err != nil {
+			log.Printf("Error removing temporary ban: %v", err)
+		}
+	}
+	if action == actionTimeout {
+		note, _ := b.notifyModeratedUser(s, guild, user, action, reason, duration)
+		messages.AddMessage(note)
+	}
+	return messages, nil
+}
+
+// authorizeAction checks that the member who triggered the interaction may perform the action.
+// Overrides are those of the action's own command, whichever command or button it's performed through.
+func (b *Bot) authorizeAction(s *discordgo.Session, i *discordgo.InteractionCreate, action string) *discordgo.MessageEmbed {
+	switch action {
+	case actionTimeout:
+		return auth.QuickAuthModerateMembersOrOverrideFor(b.pm, s, i, cmdTimeout)
+	case actionKick:
+		return auth.QuickAuthKickMembersOrOverrideFor(b.pm, s, i, cmdKick)
+	case actionBan:
+		return auth.QuickAuthBanMembersOrOverrideFor(b.pm, s, i, cmdBan)
+	case actionTempBan:
+		return auth.QuickAuthBanMembersOrOverrideFor(b.pm, s, i, cmdTempBan)
+	case actionIsolate:
+		return auth.QuickAuthManageRolesOrOverrideFor(b.pm, s, i, cmdIsolate)
+	}
+	return utils.CreateNotAllowedEmbed("Can't do that", fmt.Sprintf("The bot can't perform a %v.", action))
+}
+
+// notifyModeratedUser DMs the user about an action taken against them, returning a note for the moderator and the DM if it was sent
+func (b *Bot) notifyModeratedUser(s *discordgo.Session, guild *discordgo.Guild, user *discordgo.User, action, reason string, duration time.Duration) (string, *discordgo.Message) {
+	var title string
+	switch action {
+	case actionTimeout:
+		title = fmt.Sprintf("You have been timed out in %v", guild.Name)
+	case actionKick:
+		title = fmt.Sprintf("You have been kicked from %v", guild.Name)
+	case actionTempBan:
+		title = fmt.Sprintf("You have been temporarily banned from %v", guild.Name)
+	default:
+		title = fmt.Sprintf("You have been banned from %v", guild.Name)
+	}
+	embed := &discordgo.MessageEmbed{
+		Title:     title,
+		Color:     0xFF0000, // Red
+		Timestamp: time.Now().Format(time.RFC3339),
+		Fields: []*discordgo.MessageEmbedField{
+			{
+				Name:  "Reason",
+				Value: reasonOrDefault(reason),
+			},
+		},
+	}
+	if duration > 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  "Duration",
+			Value: utils.FormatDuration(duration),
+		})
+	}
+
+	channel, err := s.UserChannelCreate(user.ID)
+	if err != nil {
+		log.Printf("Error sending moderation DM: %v", err)
+		return fmt.Sprintf("Could not DM %v (their DMs may be closed).", user.Mention()), nil
+	}
+	dm, err := s.ChannelMessageSendEmbed(channel.ID, embed)
+	if err != nil {
+		log.Printf("Error sending moderation DM: %v", err)
+		return fmt.Sprintf("Could not DM %v (their DMs may be closed).", user.Mention()), nil
+	}
+	return fmt.Sprintf("Sent %v a DM with the reason.", user.Mention()), dm
+}
+
+// retractModerationDM takes back the DM about an action that then failed, or corrects it if it can't be deleted
+func (b *Bot) retractModerationDM(s *discordgo.Session, guild *discordgo.Guild, dm *discordgo.Message, action string) {
+	err := s.ChannelMessageDelete(dm.ChannelID, dm.ID)
+	if err == nil {
+		return
+	}
+	log.Printf("Error deleting moderation DM: %v", err)
+	_, err = s.ChannelMessageSendEmbed(dm.ChannelID, &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("Correction from %v", guild.Name),
+		Description: fmt.Sprintf("Please disregard the previous message, the %v did not go through.", action),
+		Color:       0x0000FF, // Blue
+		Timestamp:   time.Now().Format(time.RFC3339),
+	})
+	if err != nil {
+		log.Printf("Error sending moderation DM correction: %v", err)
+	}
+}
+
+// scheduleUnban records when a temporary ban should be lifted
+func (b *Bot) scheduleUnban(guildID string, user *discordgo.User, duration time.Duration, c *cases.Case) utils.Messages {
+	messages := utils.Messages{}
+	t := cases.TempBan{
+		GuildID:   guildID,
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(duration),
+	}
+	if c != nil {
+		t.CaseNumber = c.Number
+	}
+	err := b.cm.AddTempBan(t)
+	if err != nil {
+		log.Printf("Error saving temporary ban: %v", err)
+		messages.AddMessage("**Could not schedule the unban.** Please unban the user manually when the time is up.")
+		return messages
+	}
+	messages.AddMessage(fmt.Sprintf("%v will be unbanned <t:%v:R>.", user.Mention(), t.ExpiresAt.Unix()))
+	return messages
+}
+
+// runExpiryScheduler lifts expired temporary bans and isolations until stop is closed
+func (b *Bot) runExpiryScheduler(stop <-chan struct{}) {
+	ticker := time.NewTicker(expiryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			b.liftExpiredBans()
+			b.liftExpiredIsolations()
+		}
+	}
+}
+
+func (b *Bot) liftExpiredBans() {
+	s := b.Session
+	expired, err := b.cm.ExpiredTempBans(time.Now())
+	if err != nil {
+		log.Printf("Error fetching expired bans: %v", err)
+		return
+	}
+
+	for _, t := range expired {
+		reason := "Temporary ban expired"
+		b.tracker.expect(t.GuildID, t.UserID, actionUnban)
+		err := s.GuildBanDelete(t.GuildID, t.UserID, discordgo.WithAuditLogReason(reason))
+		if err != nil {
+			b.tracker.release(t.GuildID, t.UserID, actionUnban)
+		}
+		if err != nil && !utils.CheckError(err, discordgo.ErrCodeUnknownBan) {
+			// Try again next time
+			log.Printf("Error lifting temporary ban of %v in %v: %v", t.UserID, t.GuildID, err)
+			continue
+		}
+		err = b.cm.RemoveTempBan(t.GuildID, t.UserID)
+		if err != nil {
+			log.Printf("Error removing temporary ban: %v", err)
+		}
+
+		c := &cases.Case{
+			GuildID:     t.GuildID,
+			ModeratorID: s.State.User.ID,
+			TargetID:    t.UserID,
+			Action:      actionUnban,
+			Reason:      reason,
+			CreatedAt:   time.Now(),
+			LinkedCase:  t.CaseNumber,
+		}
+		err = b.recordCase(s, c, nil)
+		if err != nil {
+			log.Printf("Error logging expired ban: %v", err)
+		}
+	}
+}
+
+// reasonOrDefault returns the reason, or a placeholder if none was given
+func reasonOrDefault(reason string) string {
+	if reason == "" {
+		return "No reason provided."
+	}
+	return reason
+}
@@ -0,0 +1,408 @@
+package bot
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/shininglegend/shieldbot/pkg/auth"
+	"github.com/shininglegend/shieldbot/pkg/utils"
+)
+
+// promptDM answers a DM from a user without an open modmail thread.
+// They can pick a shared server to contact, and appeal if they're isolated somewhere.
+func (b *Bot) promptDM(s *discordgo.Session, m *discordgo.MessageCreate) {
+	buttons, messages := b.appealButtons(s, m.Author.ID)
+
+	// Offer every shared guild that takes modmail
+	var options []discordgo.SelectMenuOption
+	for _, guild := range s.State.Guilds {
+		if _, err := b.pm.GetModmailChannelID(guild.ID); err != nil {
+			continue
+		}
+		if !isMember(s, guild.ID, m.Author.ID) {
+			continue
+		}
+		// Discord allows at most 25 options
+		if len(options) == 25 {
+			break
+		}
+		options = append(options, discordgo.SelectMenuOption{
+			Label: truncate(guild.Name, 100),
+			Value: guild.ID,
+		})
+	}
+
+	var components []discordgo.MessageComponent
+	description := "Pick a server below to send your message to its moderators."
+	if len(options) > 0 {
+		components = append(components, discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.SelectMenu{
+				CustomID:    compModmailOpen,
+				Placeholder: "Choose a server",
+				Options:     options,
+			},
+		}})
+	} else {
+		description = "None of the servers we share take messages through me. Please contact their moderators directly."
+	}
+	if len(buttons) > 0 {
+		components = append(components, discordgo.ActionsRow{Components: buttons})
+		description += "\nYou are isolated in a server. Press an appeal button below to ask its moderators to reconsider."
+	}
+
+	// Reply to the message, so it can be forwarded once a server is picked
+	_, err := s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{
+		Embed: &discordgo.MessageEmbed{
+			Title:       "Contact the moderators",
+			Description: messages.GetMessages(description),
+			Color:       0x0000FF, // Blue
+		},
+		Components: components,
+		Reference:  m.Reference(),
+	})
+	if err != nil {
+		log.Printf("Error sending modmail prompt: %v", err)
+	}
+}
+
+// relayModmail forwards a DM to the user's open modmail thread.
+// Returns false if the user has no open thread.
+func (b *Bot) relayModmail(s *discordgo.Session, m *discordgo.MessageCreate) bool {
+	var threadID string
+	err := b.db.QueryRow("SELECT thread_id FROM modmail_threads WHERE user_id = ? AND closed = 0", m.Author.ID).Scan(&threadID)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("Error fetching modmail thread: %v", err)
+		}
+		return false
+	}
+
+	err = b.forwardToThread(s, threadID, m.Message)
+	if err != nil {
+		log.Printf("Error relaying modmail: %v", err)
+		_, _ = s.ChannelMessageSend(m.ChannelID, "Your message couldn't be delivered, please try again later.")
+		return true
+	}
+	err = s.MessageReactionAdd(m.ChannelID, m.ID, "📨")
+	if err != nil {
+		log.Printf("Error reacting to modmail: %v", err)
+	}
+	return true
+}
+
+// forwardToThread posts a user's message in a modmail thread
+func (b *Bot) forwardToThread(s *discordgo.Session, threadID string, m *discordgo.Message) error {
+	embed := &discordgo.MessageEmbed{
+		Author: &discordgo.MessageEmbedAuthor{
+			Name:    m.Author.Username,
+			IconURL: m.Author.AvatarURL(""),
+		},
+		Description: m.Content,
+		Color:       0x0000FF, // Blue
+		Timestamp:   m.Timestamp.Format(time.RFC3339),
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "User message",
+		},
+	}
+	if len(m.Attachments) > 0 {
+		var links []string
+		for _, attachment := range m.Attachments {
+			links = append(links, fmt.Sprintf("[%v](%v)", attachment.Filename, attachment.URL))
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  "Attachments",
+			Value: truncate(strings.Join(links, "\n"), 1024),
+		})
+		embed.Image = &discordgo.MessageEmbedImage{URL: m.Attachments[0].URL}
+	}
+	_, err := s.ChannelMessageSendEmbed(threadID, embed)
+	return err
+}
+
+// handleModmailOpen opens a thread in the chosen guild's modmail channel
+func (b *Bot) handleModmailOpen(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	user := utils.SafeUser(i.Interaction)
+	guildID := i.MessageComponentData().Values[0]
+
+	// Only one conversation at a time
+	var exists int
+	err := b.db.QueryRow("SELECT 1 FROM modmail_threads WHERE user_id = ? AND closed = 0", user.ID).Scan(&exists)
+	if err == nil {
+		respondEphemeral(s, i, utils.CreateNotAllowedEmbed("Already open", "You already have an open conversation, just send your messages here."))
+		return
+	}
+
+	channelID, err := b.pm.GetModmailChannelID(guildID)
+	if err != nil {
+		respondEphemeral(s, i, utils.CreateNotAllowedEmbed("Unavailable", "That server doesn't take messages through me anymore."))
+		return
+	}
+
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredMessageUpdate,
+	})
+	if err != nil {
+		log.Printf("Error acknowledging interaction: %v", err)
+		return
+	}
+
+	// The thread hangs off a message describing the user
+	starter, err := s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Content: fmt.Sprintf("User ID: %v", user.ID),
+		Embed: &discordgo.MessageEmbed{
+			Title:       "New Modmail",
+			Description: fmt.Sprintf("%v `%v` opened a conversation. Use /reply in the thread to answer and /close when done. Other messages in the thread are not sent to the user.", user.Mention(), user.ID),
+			Color:       0x0000FF, // Blue
+			Timestamp:   time.Now().Format(time.RFC3339),
+			Thumbnail:   &discordgo.MessageEmbedThumbnail{URL: user.AvatarURL("")},
+		},
+	})
+	if err != nil {
+		log.Printf("Error starting modmail: %v", err)
+		followupEphemeral(s, i, utils.CreateNotAllowedEmbed("Unavailable", "Your message couldn't be delivered, please try again later."))
+		return
+	}
+	thread, err := s.MessageThreadStartComplex(channelID, starter.ID, &discordgo.ThreadStart{
+		Name:                truncate(fmt.Sprintf("%v (%v)", user.Username, user.ID), 100),
+		AutoArchiveDuration: 10080, // 1 week
+	})
+	if err != nil {
+		log.Printf("Error starting modmail thread: %v", err)
+		followupEphemeral(s, i, utils.CreateNotAllowedEmbed("Unavailable", "Your message couldn't be delivered, please try again later."))
+		return
+	}
+
+	_, err = b.db.Exec("INSERT INTO modmail_threads (thread_id, user_id, guild_id, opened_at, closed) VALUES (?, ?, ?, ?, 0)",
+		thread.ID, user.ID, guildID, time.Now().Unix())
+	if err != nil {
+		log.Printf("Error saving modmail thread: %v", err)
+		followupEphemeral(s, i, utils.CreateNotAllowedEmbed("Unavailable", "Your message couldn't be delivered, please try again later."))
+		return
+	}
+
+	// Forward the message that started it all
+	if ref := i.Message.MessageReference; ref != nil {
+		original, err := s.ChannelMessage(ref.ChannelID, ref.MessageID)
+		if err != nil {
+			log.Printf("Error fetching original modmail message: %v", err)
+		} else if err = b.forwardToThread(s, thread.ID, original); err != nil {
+			log.Printf("Error relaying modmail: %v", err)
+		}
+	}
+
+	_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Embeds: &[]*discordgo.MessageEmbed{utils.CreateEmbed("Message sent",
+			fmt.Sprintf("Your message was sent to the moderators of %v. Anything else you send here will be passed along, and their replies will show up here.", guildName(s, guildID)))},
+		Components: &[]discordgo.MessageComponent{},
+	})
+	if err != nil {
+		log.Printf("Error updating modmail prompt: %v", err)
+	}
+}
+
+// getModmailUser returns the user whose open modmail thread is the given channel
+func (b *Bot) getModmailUser(channelID string) (string, error) {
+	var userID string
+	err := b.db.QueryRow("SELECT user_id FROM modmail_threads WHERE thread_id = ? AND closed = 0", channelID).Scan(&userID)
+	return userID, err
+}
+
+func (b *Bot) handleModmailReply(s *discordgo.Session, i *discordgo.InteractionCreate) *discordgo.MessageEmbed {
+	if e := auth.QuickAuthManageMessagesOrOverride(b.pm, s, i); e != nil {
+		return e
+	}
+	options := i.ApplicationCommandData().Options
+	message := options[0].StringValue()
+	anonymous := false
+	if opt := utils.FindOption(options, "anonymous"); opt != nil {
+		anonymous = opt.BoolValue()
+	}
+
+	userID, err := b.getModmailUser(i.ChannelID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return utils.CreateNotAllowedEmbed("Not a modmail thread", "Use this command in an open modmail thread.")
+		}
+		return utils.CreateErrorEmbed(s, i, "Failed to fetch modmail thread", err)
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("Reply from the moderators of %v", guildName(s, i.GuildID)),
+		Description: message,
+		Color:       0x00FF00, // Green
+		Timestamp:   time.Now().Format(time.RFC3339),
+	}
+	if !anonymous {
+		embed.Author = &discordgo.MessageEmbedAuthor{
+			Name:    i.Member.User.Username,
+			IconURL: i.Member.User.AvatarURL(""),
+		}
+	}
+	err = utils.SendDM(s, userID, embed)
+	if err != nil {
+		log.Printf("Error sending modmail reply: %v", err)
+		return utils.CreateNotAllowedEmbed("Reply not sent", "Could not DM the user, their DMs may be closed.")
+	}
+
+	// Show staff exactly what was sent
+	sent := &discordgo.MessageEmbed{
+		Author: &discordgo.MessageEmbedAuthor{
+			Name:    i.Member.User.Username,
+			IconURL: i.Member.User.AvatarURL(""),
+		},
+		Description: message,
+		Color:       0x00FF00, // Green
+		Timestamp:   embed.Timestamp,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "Staff reply",
+		},
+	}
+	if anonymous {
+		sent.Footer.Text = "Staff reply (anonymous)"
+	}
+	return sent
+}
+
+func (b *Bot) handleModmailClose(s *discordgo.Session, i *discordgo.InteractionCreate) *discordgo.MessageEmbed {
+	if e := auth.QuickAuthManageMessagesOrOverride(b.pm, s, i); e != nil {
+		return e
+	}
+	reason := "No reason provided."
+	if opt := utils.FindOption(i.ApplicationCommandData().Options, "reason"); opt != nil {
+		reason = opt.StringValue()
+	}
+
+	userID, err := b.getModmailUser(i.ChannelID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return utils.CreateNotAllowedEmbed("Not a modmail thread", "Use this command in an open modmail thread.")
+		}
+		return utils.CreateErrorEmbed(s, i, "Failed to fetch modmail thread", err)
+	}
+	messages := utils.Messages{}
+
+	_, err = b.db.Exec("UPDATE modmail_threads SET closed = 1 WHERE thread_id = ?", i.ChannelID)
+	if err != nil {
+		return utils.CreateErrorEmbed(s, i, "Failed to close modmail thread", err)
+	}
+
+	// Save the transcript to the log channel
+	transcript, err := b.modmailTranscript(s, i.ChannelID)
+	if err != nil {
+		log.Printf("Error building transcript: %v", err)
+		messages.AddMessage("Failed to build the transcript.")
+	} else {
+		_, err = b.sendUserLog(s, i.GuildID, userID, &discordgo.MessageSend{
+			Embed: &discordgo.MessageEmbed{
+				Title:       "Modmail Closed",
+				Description: fmt.Sprintf("Conversation with <@%v> `%v` in <#%v> was closed by %v.", userID, userID, i.ChannelID, i.Member.User.Mention()),
+				Color:       0x0000FF, // Blue
+				Timestamp:   time.Now().Format(time.RFC3339),
+				Fields: []*discordgo.MessageEmbedField{
+					{
+						Name:  "Reason",
+						Value: reason,
+					},
+				},
+			},
+			Files: []*discordgo.File{
+				{
+					Name:        fmt.Sprintf("modmail-%v.txt", userID),
+					ContentType: "text/plain",
+					Reader:      strings.NewReader(transcript),
+				},
+			},
+		})
+		if err == sql.ErrNoRows {
+			messages.AddMessage("No log channel set, the transcript was not saved.")
+		} else if err != nil {
+			log.Printf("Error sending transcript: %v", err)
+			messages.AddMessage("Failed to send the transcript to the log channel.")
+		}
+	}
+
+	err = utils.SendDM(s, userID, &discordgo.MessageEmbed{
+		Title:       "Conversation closed",
+		Description: fmt.Sprintf("The moderators of %v closed this conversation. Send another message if you need them again.", guildName(s, i.GuildID)),
+		Color:       0x0000FF, // Blue
+		Timestamp:   time.Now().Format(time.RFC3339),
+	})
+	if err != nil {
+		messages.AddMessage("Could not DM the user that the conversation was closed.")
+	}
+
+	// Leave a note in the thread, then lock it away
+	_, err = s.ChannelMessageSendEmbed(i.ChannelID, utils.CreateEmbed("Modmail closed", fmt.Sprintf("Closed by %v: %v", i.Member.User.Mention(), reason)))
+	if err != nil {
+		log.Printf("Error sending close message: %v", err)
+	}
+	archived, locked := true, true
+	_, err = s.ChannelEdit(i.ChannelID, &discordgo.ChannelEdit{
+		Archived: &archived,
+		Locked:   &locked,
+	})
+	if err != nil {
+		log.Printf("Error archiving modmail thread: %v", err)
+		messages.AddMessage("Failed to archive the thread.")
+	}
+
+	return utils.CreateEmbed("Modmail closed", messages.GetMessages("The conversation has been closed."))
+}
+
+// modmailTranscript builds a plain text transcript of a modmail thread
+func (b *Bot) modmailTranscript(s *discordgo.Session, threadID string) (string, error) {
+	var all []*discordgo.Message
+	beforeID := ""
+	for {
+		batch, err := s.ChannelMessages(threadID, 100, beforeID, "", "")
+		if err != nil {
+			return "", err
+		}
+		all = append(all, batch...)
+		if len(batch) < 100 {
+			break
+		}
+		beforeID = batch[len(batch)-1].ID
+	}
+
+	var transcript strings.Builder
+	// Messages come newest first
+	for idx := len(all) - 1; idx >= 0; idx-- {
+		msg := all[idx]
+		author, content := msg.Author.Username, msg.Content
+		// Relayed messages and replies are embeds
+		if len(msg.Embeds) > 0 {
+			embed := msg.Embeds[0]
+			if embed.Author != nil {
+				author = embed.Author.Name
+			} else if embed.Title != "" {
+				author = embed.Title
+			}
+			content = embed.Description
+			if embed.Footer != nil {
+				author = fmt.Sprintf("%v [%v]", author, embed.Footer.Text)
+			}
+			for _, field := range embed.Fields {
+				content = fmt.Sprintf("%v\n  %v: %v", content, field.Name, field.Value)
+			}
+		}
+		for _, attachment := range msg.Attachments {
+			content = fmt.Sprintf("%v\n  Attachment: %v", content, attachment.URL)
+		}
+		transcript.WriteString(fmt.Sprintf("[%v] %v: %v\n", msg.Timestamp.UTC().Format("2006-01-02 15:04"), author, content))
+	}
+	return transcript.String(), nil
+}
+
+// isMember checks whether a user is in a guild, preferring the state cache
+func isMember(s *discordgo.Session, guildID, userID string) bool {
+	if _, err := s.State.Member(guildID, userID); err == nil {
+		return true
+	}
+	_, err := s.GuildMember(guildID, userID)
+	return err == nil
+}
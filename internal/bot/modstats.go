@@ -0,0 +1,144 @@
+package bot
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/shininglegend/shieldbot/internal/cases"
+	"github.com/shininglegend/shieldbot/pkg/auth"
+	"github.com/shininglegend/shieldbot/pkg/utils"
+)
+
+const (
+	defaultStatsPeriod = 30 * 24 * time.Hour
+	// Rows of each /modstats list, to stay within embed limits
+	maxStatsModerators = 15
+	maxStatsActions    = 5
+)
+
+// moderatorStats counts what one moderator logged
+type moderatorStats struct {
+	id         string
+	total      int
+	isolations int
+	restores   int
+}
+
+func (b *Bot) handleModStats(s *discordgo.Session, i *discordgo.InteractionCreate) *discordgo.MessageEmbed {
+	if e := auth.QuickAuthAdminOrOverride(b.pm, s, i); e != nil {
+		return e
+	}
+	options := i.ApplicationCommandData().Options
+	period := defaultStatsPeriod
+	if opt := utils.FindOption(options, "period"); opt != nil {
+		d, err := utils.ParseDuration(opt.StringValue())
+		if err != nil {
+			return utils.CreateNotAllowedEmbed("Invalid period", "Use a period like `7d`, `4w` or `90d`.")
+		}
+		period = d
+	}
+	since := time.Now().Add(-period)
+
+	// Isolation durations need isolations from before the period, so fetch everything
+	all, err := b.cm.SearchCases(cases.Filter{GuildID: i.GuildID})
+	if err != nil {
+		return utils.CreateErrorEmbed(s, i, "Failed to fetch cases", err)
+	}
+
+	moderators := make(map[string]*moderatorStats)
+	actions := make(map[string]int)
+	isolatedAt := make(map[string]time.Time) // Open isolations by user
+	var isolated time.Duration
+	completed, total := 0, 0
+	for _, c := range all {
+		inPeriod := !c.CreatedAt.Before(since)
+		switch c.Action {
+		case actionIsolate:
+			isolatedAt[c.TargetID] = c.CreatedAt
+		case actionRestore:
+			if start, ok := isolatedAt[c.TargetID]; ok {
+				delete(isolatedAt, c.TargetID)
+				if inPeriod {
+					isolated += c.CreatedAt.Sub(start)
+					completed++
+				}
+			}
+		}
+		if !inPeriod {
+			continue
+		}
+
+		total++
+		actions[c.Action]++
+		m, ok := moderators[c.ModeratorID]
+		if !ok {
+			m = &moderatorStats{id: c.ModeratorID}
+			moderators[c.ModeratorID] = m
+		}
+		m.total++
+		switch c.Action {
+		case actionIsolate:
+			m.isolations++
+		case actionRestore:
+			m.restores++
+		}
+	}
+
+	embed := utils.CreateEmbed("Moderation Stats", fmt.Sprintf("%v case(s) logged since <t:%v:D> (last %v).", total, since.Unix(), utils.FormatDuration(period)))
+	embed.Color = 0x0000FF // Blue
+	if total == 0 {
+		return embed
+	}
+
+	// Busiest moderators first
+	ranked := make([]*moderatorStats, 0, len(moderators))
+	for _, m := range moderators {
+		ranked = append(ranked, m)
+	}
+	sort.Slice(ranked, func(a, b int) bool {
+		if ranked[a].total != ranked[b].total {
+			return ranked[a].total > ranked[b].total
+		}
+		return ranked[a].id < ranked[b].id
+	})
+	var moderatorLines []string
+	for n, m := range ranked {
+		if n == maxStatsModerators {
+			moderatorLines = append(moderatorLines, fmt.Sprintf("*...and %v more*", len(ranked)-n))
+			break
+		}
+		moderatorLines = append(moderatorLines, fmt.Sprintf("<@%v>: %v case(s), %v isolation(s), %v restore(s)", m.id, m.total, m.isolations, m.restores))
+	}
+
+	// Most common actions first
+	actionNames := make([]string, 0, len(actions))
+	for action := range actions {
+		actionNames = append(actionNames, action)
+	}
+	sort.Slice(actionNames, func(a, b int) bool {
+		if actions[actionNames[a]] != actions[actionNames[b]] {
+			return actions[actionNames[a]] > actions[actionNames[b]]
+		}
+		return actionNames[a] < actionNames[b]
+	})
+	var actionLines []string
+	for _, action := range actionNames[:min(len(actionNames), maxStatsActions)] {
+		label, _, _ := b.actionType(i.GuildID, action)
+		actionLines = append(actionLines, fmt.Sprintf("%v: %v", label, actions[action]))
+	}
+
+	average := "No isolations were restored in this period."
+	if completed > 0 {
+		average = fmt.Sprintf("%v, over %v restored isolation(s)", utils.FormatDuration(isolated/time.Duration(completed)), completed)
+	}
+
+	embed.Fields = []*discordgo.MessageEmbedField{
+		{Name: "Moderators", Value: strings.Join(moderatorLines, "\n")},
+		{Name: "Most common actions", Value: strings.Join(actionLines, "\n"), Inline: true},
+		{Name: "Average isolation", Value: average, Inline: true},
+	}
+	return embed
+}
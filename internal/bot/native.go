@@ -0,0 +1,293 @@
+// This file logs bans, kicks and timeouts done through Discord itself rather than the bot
+package bot
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/shininglegend/shieldbot/internal/cases"
+)
+
+const (
+	// How long a gateway event waits for its audit log entry before falling back
+	auditLogWait = 5 * time.Second
+	// How long an action is remembered, so it's only logged once
+	trackedActionExpiry = 2 * time.Minute
+)
+
+// actionTracker makes sure each ban, unban, kick and timeout is logged once.
+// Discord reports an action through both the audit log and a gateway event, which are told apart by
+// audit log entry ID, and the bot expects the events of its own actions, which it logs itself.
+type actionTracker struct {
+	mu       sync.Mutex
+	entries  map[string]time.Time // Audit log entries already logged
+	expected map[string]time.Time // guild:user:action done by the bot and not yet seen
+}
+
+func newActionTracker() *actionTracker {
+	return &actionTracker{
+		entries:  make(map[string]time.Time),
+		expected: make(map[string]time.Time),
+	}
+}
+
+// actionKey identifies an action on a user, without telling repeats apart
+func actionKey(guildID, userID, action string) string {
+	// A temporary ban is still a ban to Discord
+	if action == actionTempBan {
+		action = actionBan
+	}
+	return fmt.Sprintf("%v:%v:%v", guildID, userID, action)
+}
+
+// forgetExpired drops what's been remembered for too long. The caller holds the lock.
+func (t *actionTracker) forgetExpired() {
+	now := time.Now()
+	for _, m := range []map[string]time.Time{t.entries, t.expected} {
+		for k, at := range m {
+			if now.Sub(at) > trackedActionExpiry {
+				delete(m, k)
+			}
+		}
+	}
+}
+
+// expect is called before the bot does an action, so its event isn't logged again
+func (t *actionTracker) expect(guildID, userID, action string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.forgetExpired()
+	t.expected[actionKey(guildID, userID, action)] = time.Now()
+}
+
+// release undoes expect when the action failed, since no event will come
+func (t *actionTracker) release(guildID, userID, action string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.expected, actionKey(guildID, userID, action))
+}
+
+// takeExpected returns true if the bot did the action, so only its first event is skipped
+func (t *actionTracker) takeExpected(guildID, userID, action string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.forgetExpired()
+	key := actionKey(guildID, userID, action)
+	if _, ok := t.expected[key]; !ok {
+		return false
+	}
+	delete(t.expected, key)
+	return true
+}
+
+// claimEntry returns true if the audit log entry hasn't been logged yet, and marks it as logged
+func (t *actionTracker) claimEntry(entryID string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.forgetExpired()
+	if _, ok := t.entries[entryID]; ok {
+		return false
+	}
+	t.entries[entryID] = time.Now()
+	return true
+}
+
+// nativeAction is a moderation action found in the audit log or a gateway event
+type nativeAction struct {
+	guildID     string
+	targetID    string
+	moderatorID string // Empty if unknown
+	entryID     string // Audit log entry, empty if it wasn't found
+	action      string
+	reason      string
+	until       *time.Time // End of a timeout
+}
+
+// handleAuditLogEvent logs moderation actions as they're added to the audit log.
+// discordgo's GuildAuditLogEntryCreate has no guild ID, so the raw event is read instead.
+func (b *Bot) handleAuditLogEvent(s *discordgo.Session, e *discordgo.Event) {
+	if e.Type != "GUILD_AUDIT_LOG_ENTRY_CREATE" {
+		return
+	}
+	var entry struct {
+		discordgo.AuditLogEntry
+		GuildID string `json:"guild_id"`
+	}
+	err := json.Unmarshal(e.RawData, &entry)
+	if err != nil {
+		log.Printf("Error reading audit log entry: %v", err)
+		return
+	}
+
+	a, ok := auditLogAction(&entry.AuditLogEntry)
+	if !ok {
+		return
+	}
+	a.guildID = entry.GuildID
+	b.logNativeAction(s, a)
+}
+
+// auditLogAction converts an audit log entry, returning false if it isn't a ban, unban, kick or new timeout
+func auditLogAction(entry *discordgo.AuditLogEntry) (nativeAction, bool) {
+	a := nativeAction{
+		entryID:     entry.ID,
+		targetID:    entry.TargetID,
+		moderatorID: entry.UserID,
+		reason:      entry.Reason,
+	}
+	if entry.ActionType == nil {
+		return a, false
+	}
+	switch *entry.ActionType {
+	case discordgo.AuditLogActionMemberBanAdd:
+		a.action = actionBan
+	case discordgo.AuditLogActionMemberBanRemove:
+		a.action = actionUnban
+	case discordgo.AuditLogActionMemberKick:
+		a.action = actionKick
+	case discordgo.AuditLogActionMemberUpdate:
+		for _, change := range entry.Changes {
+			if change.Key == nil || *change.Key != discordgo.AuditLogChangeKeyCommunicationDisabledUntil {
+				continue
+			}
+			// Removing a timeout sets it to null
+			value, ok := change.NewValue.(string)
+			if !ok {
+				return a, false
+			}
+			until, err := time.Parse(time.RFC3339, value)
+			if err != nil || until.Before(time.Now()) {
+				return a, false
+			}
+			a.action = actionTimeout
+			a.until = &until
+		}
+		if a.action == "" {
+			return a, false
+		}
+	default:
+		return a, false
+	}
+	return a, true
+}
+
+func (b *Bot) handleBanAdd(s *discordgo.Session, e *discordgo.GuildBanAdd) {
+	b.awaitAuditLog(s, nativeAction{guildID: e.GuildID, targetID: e.User.ID, action: actionBan})
+}
+
+func (b *Bot) handleBanRemove(s *discordgo.Session, e *discordgo.GuildBanRemove) {
+	b.awaitAuditLog(s, nativeAction{guildID: e.GuildID, targetID: e.User.ID, action: actionUnban})
+}
+
+func (b *Bot) handleMemberUpdate(s *discordgo.Session, e *discordgo.GuildMemberUpdate) {
+	// Without the previous state there's no telling whether the timeout is new
+	if e.Member == nil || e.User == nil || e.BeforeUpdate == nil {
+		return
+	}
+	until := e.CommunicationDisabledUntil
+	if until == nil || until.Before(time.Now()) {
+		return
+	}
+	before := e.BeforeUpdate.CommunicationDisabledUntil
+	if before != nil && before.Equal(*until) {
+		return
+	}
+	b.awaitAuditLog(s, nativeAction{guildID: e.GuildID, targetID: e.User.ID, action: actionTimeout, until: until})
+}
+
+// awaitAuditLog gives the audit log entry of a gateway event time to arrive, since it names the moderator.
+// If it doesn't, the audit log is searched instead, and the action is logged with whatever is known.
+func (b *Bot) awaitAuditLog(s *discordgo.Session, a nativeAction) {
+	time.Sleep(auditLogWait)
+
+	actionType := map[string]discordgo.AuditLogAction{
+		actionBan:     discordgo.AuditLogActionMemberBanAdd,
+		actionUnban:   discordgo.AuditLogActionMemberBanRemove,
+		actionTimeout: discordgo.AuditLogActionMemberUpdate,
+	}[a.action]
+	auditLog, err := s.GuildAuditLog(a.guildID, "", "", int(actionType), 10)
+	if err != nil {
+		log.Printf("Error fetching audit log, logging %v without a moderator: %v", a.action, err)
+	} else {
+		for _, entry := range auditLog.AuditLogEntries {
+			found, ok := auditLogAction(entry)
+			if !ok || found.targetID != a.targetID || found.action != a.action {
+				continue
+			}
+			// Only trust recent entries
+			if created, err := discordgo.SnowflakeTimestamp(entry.ID); err != nil || time.Since(created) > trackedActionExpiry {
+				continue
+			}
+			found.guildID = a.guildID
+			a = found
+			break
+		}
+	}
+	if a.entryID == "" {
+		// Without the entry there's no telling whether the bot did it, other than it expecting this
+		if b.tracker.takeExpected(a.guildID, a.targetID, a.action) {
+			return
+		}
+		b.recordNativeAction(s, a)
+		return
+	}
+	b.logNativeAction(s, a)
+}
+
+// logNativeAction logs an action from the audit log, unless its entry was already logged
+func (b *Bot) logNativeAction(s *discordgo.Session, a nativeAction) {
+	if !b.tracker.claimEntry(a.entryID) {
+		return
+	}
+	b.recordNativeAction(s, a)
+}
+
+// recordNativeAction posts and stores the case, skipping actions done by the bot, which logs those itself
+func (b *Bot) recordNativeAction(s *discordgo.Session, a nativeAction) {
+	if a.moderatorID == s.State.User.ID {
+		b.tracker.takeExpected(a.guildID, a.targetID, a.action)
+		return
+	}
+
+	// A manual unban replaces any scheduled one
+	if a.action == actionUnban {
+		err := b.cm.RemoveTempBan(a.guildID, a.targetID)
+		if err != nil {
+			log.Printf("Error removing temporary ban: %v", err)
+		}
+	}
+
+	points, err := b.cm.GetActionPoints(a.guildID, a.action)
+	if err != nil {
+		log.Printf("Error getting action points: %v", err)
+	}
+	details := "Done through Discord rather than the bot."
+	if a.until != nil {
+		details = fmt.Sprintf("%v Timed out until <t:%v:f>.", details, a.until.Unix())
+	}
+	c := &cases.Case{
+		GuildID:     a.guildID,
+		ModeratorID: a.moderatorID,
+		TargetID:    a.targetID,
+		Action:      a.action,
+		Reason:      a.reason,
+		CreatedAt:   time.Now(),
+		Points:      points,
+		Details:     details,
+	}
+	if c.ModeratorID == "" {
+		// The bot can't see who did it without the View Audit Log permission
+		c.ModeratorID = s.State.User.ID
+		c.Details = fmt.Sprintf("%v The moderator is unknown, give the bot the View Audit Log permission to record it.", c.Details)
+	}
+
+	err = b.recordCase(s, c, nil)
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("Error logging %v done through Discord: %v", a.action, err)
+	}
+}
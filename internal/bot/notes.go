@@ -0,0 +1,135 @@
+// This file handles /note, staff-only notes on users that aren't infractions
+package bot
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/shininglegend/shieldbot/internal/cases"
+	"github.com/shininglegend/shieldbot/pkg/auth"
+	"github.com/shininglegend/shieldbot/pkg/utils"
+)
+
+const (
+	// Subcommands of /note
+	noteAdd    = "add"
+	noteList   = "list"
+	noteRemove = "remove"
+
+	maxNoteLength = 1000
+	// Notes shown by /note list, and alongside /history
+	maxListedNotes  = 25
+	maxHistoryNotes = 3
+	// Notes are cut to this in /note list, so a full list stays under Discord's 6000 character embed limit
+	listedNoteLength = 150
+)
+
+func (b *Bot) handleNote(s *discordgo.Session, i *discordgo.InteractionCreate) *discordgo.MessageEmbed {
+	if e := auth.QuickAuthManageMessagesOrOverride(b.pm, s, i); e != nil {
+		return e
+	}
+	subcommand := i.ApplicationCommandData().Options[0]
+	options := subcommand.Options
+
+	switch subcommand.Name {
+	case noteAdd:
+		user := utils.FindOption(options, "user").UserValue(s)
+		if user == nil {
+			return utils.CreateNotAllowedEmbed("User not found", "Could not find that user.")
+		}
+		text := strings.TrimSpace(utils.FindOption(options, "text").StringValue())
+		if text == "" {
+			return utils.CreateNotAllowedEmbed("Empty note", "The note can't be empty.")
+		}
+		n := &cases.Note{
+			GuildID:   i.GuildID,
+			UserID:    user.ID,
+			AuthorID:  i.Member.User.ID,
+			Text:      truncate(text, maxNoteLength),
+			CreatedAt: time.Now(),
+		}
+		err := b.cm.AddNote(n)
+		if err != nil {
+			return utils.CreateErrorEmbed(s, i, "Failed to add note", err)
+		}
+		return utils.CreateEmbed("Note Added", fmt.Sprintf("Note #%v on %v `%v`:\n>>> %v", n.ID, user.Mention(), user.ID, n.Text))
+
+	case noteList:
+		user := utils.FindOption(options, "user").UserValue(s)
+		if user == nil {
+			return utils.CreateNotAllowedEmbed("User not found", "Could not find that user.")
+		}
+		notes, err := b.cm.GetNotes(i.GuildID, user.ID)
+		if err != nil {
+			return utils.CreateErrorEmbed(s, i, "Failed to fetch notes", err)
+		}
+		embed := &discordgo.MessageEmbed{
+			Title:     fmt.Sprintf("Notes on %v", user.Username),
+			Color:     0x0000FF, // Blue
+			Thumbnail: &discordgo.MessageEmbedThumbnail{URL: user.AvatarURL("")},
+		}
+		if len(notes) == 0 {
+			embed.Description = fmt.Sprintf("%v `%v` has no notes.", user.Mention(), user.ID)
+			return embed
+		}
+		embed.Description = fmt.Sprintf("%v `%v` has %v note(s).", user.Mention(), user.ID, len(notes))
+		for _, n := range notes[:min(len(notes), maxListedNotes)] {
+			embed.Fields = append(embed.Fields, noteField(n, listedNoteLength))
+		}
+		if len(notes) > maxListedNotes {
+			embed.Footer = &discordgo.MessageEmbedFooter{
+				Text: fmt.Sprintf("Showing the newest %v of %v notes", maxListedNotes, len(notes)),
+			}
+		}
+		return embed
+
+	case noteRemove:
+		id := utils.FindOption(options, "id").IntValue()
+		n, err := b.cm.RemoveNote(i.GuildID, id)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return utils.CreateNotAllowedEmbed("Note not found", fmt.Sprintf("There is no note #%v in this server.", id))
+			}
+			return utils.CreateErrorEmbed(s, i, "Failed to remove note", err)
+		}
+		return utils.CreateEmbed("Note Removed", fmt.Sprintf("Removed note #%v on <@%v>:\n>>> %v", n.ID, n.UserID, n.Text))
+
+	default:
+		return utils.CreateNotAllowedEmbed("Unknown subcommand to note", fmt.Sprintf("Unknown subcommand: %v", subcommand.Name))
+	}
+}
+
+// noteField shows a note as an embed field, with its text cut to maxLen
+func noteField(n cases.Note, maxLen int) *discordgo.MessageEmbedField {
+	return &discordgo.MessageEmbedField{
+		Name:  fmt.Sprintf("Note #%v", n.ID),
+		Value: fmt.Sprintf("%v\nBy <@%v> <t:%v:R>", truncate(n.Text, maxLen), n.AuthorID, n.CreatedAt.Unix()),
+	}
+}
+
+// historyNotes summarizes a user's newest notes for /history, or returns nil if they have none
+func (b *Bot) historyNotes(guildID, userID string) *discordgo.MessageEmbedField {
+	notes, err := b.cm.GetNotes(guildID, userID)
+	if err != nil {
+		log.Printf("Error fetching notes: %v", err)
+		return nil
+	}
+	if len(notes) == 0 {
+		return nil
+	}
+	var lines []string
+	for _, n := range notes[:min(len(notes), maxHistoryNotes)] {
+		lines = append(lines, fmt.Sprintf("`#%v` %v - <@%v>", n.ID, truncate(n.Text, 200), n.AuthorID))
+	}
+	if len(notes) > maxHistoryNotes {
+		lines = append(lines, fmt.Sprintf("*...and %v more, see /note list*", len(notes)-maxHistoryNotes))
+	}
+	return &discordgo.MessageEmbedField{
+		Name:  fmt.Sprintf("Notes (%v)", len(notes)),
+		Value: strings.Join(lines, "\n"),
+	}
+}
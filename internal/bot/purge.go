@@ -0,0 +1,250 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/shininglegend/shieldbot/internal/logging"
+	"github.com/shininglegend/shieldbot/pkg/auth"
+	"github.com/shininglegend/shieldbot/pkg/utils"
+)
+
+const (
+	// Stop looking for matching messages after this many
+	purgeScanLimit = 1000
+	// Discord only bulk deletes messages younger than this
+	bulkDeleteMaxAge = 14 * 24 * time.Hour
+)
+
+// purgeFilter decides which messages /purge deletes
+type purgeFilter struct {
+	userID          string
+	contains        string
+	botsOnly        bool
+	attachmentsOnly bool
+	afterID         string
+}
+
+func (f purgeFilter) matches(m *discordgo.Message) bool {
+	if m.Pinned {
+		return false
+	}
+	if f.userID != "" && m.Author.ID != f.userID {
+		return false
+	}
+	if f.contains != "" && !strings.Contains(strings.ToLower(m.Content), f.contains) {
+		return false
+	}
+	if f.botsOnly && !m.Author.Bot {
+		return false
+	}
+	if f.attachmentsOnly && len(m.Attachments) == 0 {
+		return false
+	}
+	return true
+}
+
+// describe lists the filters in use for the log
+func (f purgeFilter) describe() string {
+	var parts []string
+	if f.userID != "" {
+		parts = append(parts, fmt.Sprintf("From <@%v>", f.userID))
+	}
+	if f.contains != "" {
+		parts = append(parts, fmt.Sprintf("Containing `%v`", f.contains))
+	}
+	if f.botsOnly {
+		parts = append(parts, "Bots only")
+	}
+	if f.attachmentsOnly {
+		parts = append(parts, "With attachments only")
+	}
+	if f.afterID != "" {
+		parts = append(parts, fmt.Sprintf("After message `%v`", f.afterID))
+	}
+	if len(parts) == 0 {
+		return "None"
+	}
+	return strings.Join(parts, "\n")
+}
+
+func (b *Bot) handlePurge(s *discordgo.Session, i *discordgo.InteractionCreate) *discordgo.MessageEmbed {
+	if e := auth.QuickAuthManageMessagesOrOverride(b.pm, s, i); e != nil {
+		return e
+	}
+	options := i.ApplicationCommandData().Options
+	count := int(options[0].IntValue())
+
+	filter := purgeFilter{}
+	if opt := utils.FindOption(options, "user"); opt != nil {
+		filter.userID = opt.UserValue(nil).ID
+	}
+	if opt := utils.FindOption(options, "contains"); opt != nil {
+		filter.contains = strings.ToLower(opt.StringValue())
+	}
+	if opt := utils.FindOption(options, "bots_only"); opt != nil {
+		filter.botsOnly = opt.BoolValue()
+	}
+	if opt := utils.FindOption(options, "attachments_only"); opt != nil {
+		filter.attachmentsOnly = opt.BoolValue()
+	}
+	beforeID := ""
+	if opt := utils.FindOption(options, "before"); opt != nil {
+		beforeID = strings.TrimSpace(opt.StringValue())
+	}
+	if opt := utils.FindOption(options, "after"); opt != nil {
+		filter.afterID = strings.TrimSpace(opt.StringValue())
+	}
+	for _, id := range []string{beforeID, filter.afterID} {
+		if _, err := strconv.ParseUint(id, 10, 64); id != "" && err != nil {
+			return utils.CreateNotAllowedEmbed("Invalid message ID", fmt.Sprintf("`%v` is not a message ID. Right click a message and copy its ID.", id))
+		}
+	}
+
+	// Walk back through the channel until enough messages match
+	var matched []*discordgo.Message
+	scanned := 0
+scan:
+	for scanned < purgeScanLimit && len(matched) < count {
+		batch, err := s.ChannelMessages(i.ChannelID, 100, beforeID, "", "")
+		if err != nil {
+			return utils.CreateErrorEmbed(s, i, "Failed to fetch messages", err)
+		}
+		for _, m := range batch {
+			if filter.afterID != "" && snowflakeBefore(m.ID, filter.afterID) {
+				break scan
+			}
+			scanned++
+			if filter.matches(m) {
+				matched = append(matched, m)
+				if len(matched) == count {
+					break scan
+				}
+			}
+		}
+		if len(batch) < 100 {
+			break
+		}
+		beforeID = batch[len(batch)-1].ID
+	}
+	if len(matched) == 0 {
+		return utils.CreateNotAllowedEmbed("Nothing to purge", fmt.Sprintf("No messages matched in the last %v messages checked.", scanned))
+	}
+
+	// The purge log below covers these, so the message log doesn't repeat them
+	ids := make([]string, len(matched))
+	for idx, m := range matched {
+		ids[idx] = m.ID
+	}
+	err := b.messages.Remove(ids...)
+	if err != nil {
+		log.Printf("Error removing purged messages from cache: %v", err)
+	}
+
+	deleted, failed := deleteMessages(s, i.ChannelID, matched)
+	messages := utils.Messages{}
+	if failed > 0 {
+		messages.AddMessage(fmt.Sprintf("Failed to delete %v message(s).", failed))
+	}
+
+	// Keep a record of what was removed
+	err = b.logPurge(s, i, deleted, filter)
+	if err != nil {
+		log.Printf("Error logging purge: %v", err)
+		messages.AddMessage("Could not post the purge log. Is the log channel set?")
+	}
+	return utils.CreateEmbed("Purged messages", messages.GetMessages(fmt.Sprintf("Deleted %v message(s) out of %v checked.", len(deleted), scanned)))
+}
+
+// deleteMessages bulk deletes recent messages and deletes older ones one at a time.
+// Returns the messages that were deleted and how many couldn't be.
+func deleteMessages(s *discordgo.Session, channelID string, messages []*discordgo.Message) ([]*discordgo.Message, int) {
+	var recent, old []*discordgo.Message
+	cutoff := time.Now().Add(-bulkDeleteMaxAge).Add(time.Minute) // Leave some leeway
+	for _, m := range messages {
+		if created, err := discordgo.SnowflakeTimestamp(m.ID); err == nil && created.After(cutoff) {
+			recent = append(recent, m)
+		} else {
+			old = append(old, m)
+		}
+	}
+
+	var deleted []*discordgo.Message
+	failed := 0
+	for start := 0; start < len(recent); start += 100 {
+		chunk := recent[start:min(start+100, len(recent))]
+		// Bulk delete needs at least 2 messages
+		if len(chunk) == 1 {
+			old = append(old, chunk[0])
+			continue
+		}
+		ids := make([]string, len(chunk))
+		for idx, m := range chunk {
+			ids[idx] = m.ID
+		}
+		err := s.ChannelMessagesBulkDelete(channelID, ids)
+		if err != nil {
+			log.Printf("Error bulk deleting messages: %v", err)
+			failed += len(chunk)
+			continue
+		}
+		deleted = append(deleted, chunk...)
+	}
+	for _, m := range old {
+		err := s.ChannelMessageDelete(channelID, m.ID)
+		if err != nil {
+			log.Printf("Error deleting message: %v", err)
+			failed++
+			continue
+		}
+		deleted = append(deleted, m)
+	}
+	return deleted, failed
+}
+
+// logPurge posts a summary of the purge to the message log channel, with the deleted content attached
+func (b *Bot) logPurge(s *discordgo.Session, i *discordgo.InteractionCreate, deleted []*discordgo.Message, filter purgeFilter) error {
+	// Oldest first reads more naturally
+	var transcript strings.Builder
+	for idx := len(deleted) - 1; idx >= 0; idx-- {
+		m := deleted[idx]
+		transcript.WriteString(fmt.Sprintf("[%v] %v (%v): %v\n", m.Timestamp.UTC().Format("2006-01-02 15:04"), m.Author.Username, m.Author.ID, m.Content))
+		for _, attachment := range m.Attachments {
+			transcript.WriteString(fmt.Sprintf("  Attachment: %v\n", attachment.URL))
+		}
+	}
+
+	_, err := b.logger.Send(s, i.GuildID, logging.CategoryMessages, &discordgo.MessageSend{
+		Embed: &discordgo.MessageEmbed{
+			Title:       "Messages Purged",
+			Description: fmt.Sprintf("Moderator %v purged %v message(s) in <#%v>", i.Member.User.Mention(), len(deleted), i.ChannelID),
+			Color:       0xFFA500, // Orange
+			Timestamp:   time.Now().Format(time.RFC3339),
+			Fields: []*discordgo.MessageEmbedField{
+				{
+					Name:  "Filters",
+					Value: filter.describe(),
+				},
+			},
+		},
+		Files: []*discordgo.File{
+			{
+				Name:        fmt.Sprintf("purge-%v-%v.txt", i.ChannelID, time.Now().Unix()),
+				ContentType: "text/plain",
+				Reader:      strings.NewReader(transcript.String()),
+			},
+		},
+	})
+	return err
+}
+
+// snowflakeBefore reports whether snowflake a is older than snowflake b
+func snowflakeBefore(a, b string) bool {
+	x, errA := strconv.ParseUint(a, 10, 64)
+	y, errB := strconv.ParseUint(b, 10, 64)
+	return errA == nil && errB == nil && x <= y
+}
@@ -8,17 +8,35 @@ import (
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/shininglegend/shieldbot/internal/commands"
+	"github.com/shininglegend/shieldbot/internal/export"
+	"github.com/shininglegend/shieldbot/internal/logging"
 )
 
 const (
 	// These are the names of the slash commands, to be consistent with the command handler.
-	cmdPingType   = "pings"
-	cmdHelp       = "help"
-	cmdConfigType = "config" // Subcommands elsewhere
-	cmdIsolate    = "isolate"
-	cmdRestore    = "restore"
-	cmdLogging    = "log"
-	cmdLoggingExt = "elog" // For logging of non-server-members
+	cmdPingType    = "pings"
+	cmdHelp        = "help"
+	cmdConfigType  = "config" // Subcommands elsewhere
+	cmdIsolate     = "isolate"
+	cmdRestore     = "restore"
+	cmdLogging     = "log"
+	cmdLoggingExt  = "elog"  // For logging of non-server-members
+	cmdReply       = "reply" // Modmail
+	cmdClose       = "close" // Modmail
+	cmdCase        = "case"  // Subcommands in cases.go
+	cmdHistory     = "history"
+	cmdHistoryMenu = "View History" // User context menu
+	cmdTimeout     = "timeout"
+	cmdKick        = "kick"
+	cmdBan         = "ban"
+	cmdTempBan     = "tempban"
+	cmdUnban       = "unban"
+	cmdPurge       = "purge"
+	cmdExport      = "export" // Subcommands in export.go
+	cmdModStats    = "modstats"
+	cmdNote        = "note" // Subcommands in notes.go
+	cmdWhois       = "whois"
+	cmdRules       = "rules" // Subcommands in rules.go
 )
 
 func (b *Bot) registerCommands() error {
@@ -48,85 +66,344 @@ func (b *Bot) registerCommands() error {
 					Description: "The user to isolate",
 					Required:    true,
 				},
+				{
+					Type:         discordgo.ApplicationCommandOptionString,
+					Name:         "reason",
+					Description:  "The reason for the isolation, sent to the user",
+					Required:     false,
+					Autocomplete: true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "duration",
+					Description: "How long until the user is restored, sent to them (e.g. 12h, 3d)",
+					Required:    false,
+				},
 			},
 		},
 		{
 			Name:        cmdLogging,
 			Description: "Log a moderator action. Use elog if the user isn't showing.",
-			Options: []*discordgo.ApplicationCommandOption{
+			Options: append([]*discordgo.ApplicationCommandOption{
 				{
 					Type:        discordgo.ApplicationCommandOptionUser,
 					Name:        "user",
 					Description: "The user you took action on",
 					Required:    true,
 				},
+				{
+					Type:         discordgo.ApplicationCommandOptionString,
+					Name:         "action",
+					Description:  "The action you took",
+					Required:     true,
+					Autocomplete: true,
+				},
+				{
+					Type:         discordgo.ApplicationCommandOptionString,
+					Name:         "reason",
+					Description:  "The reason for the action",
+					Required:     false,
+					Autocomplete: true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "execute",
+					Description: "Have the bot perform the timeout, kick or ban before logging it",
+					Required:    false,
+				},
 				{
 					Type:        discordgo.ApplicationCommandOptionString,
-					Name:        "action",
-					Description: "The action you took",
+					Name:        "duration",
+					Description: "How long a timeout lasts, or makes a ban temporary, when executing (e.g. 10m, 1h, 3d)",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "form",
+					Description: "Open a form for a longer reason, details and evidence links",
+					Required:    false,
+				},
+			}, evidenceOptions()...),
+		},
+		{
+			Name:        cmdLoggingExt,
+			Description: "Log a moderator action on users by ID. Use log if the user is in the server.",
+			Options: append([]*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "users",
+					Description: "IDs, mentions or usernames of the users you took action on, separated by spaces or commas",
+					Required:    true,
+				},
+				{
+					Type:         discordgo.ApplicationCommandOptionString,
+					Name:         "action",
+					Description:  "The action you took",
+					Required:     true,
+					Autocomplete: true,
+				},
+				{
+					Type:         discordgo.ApplicationCommandOptionString,
+					Name:         "reason",
+					Description:  "The reason for the action",
+					Required:     false,
+					Autocomplete: true,
+				},
+			}, evidenceOptions()...),
+		},
+		{
+			Name:         cmdRestore,
+			DMPermission: &cannotDM,
+			Description:  "Restores a user's roles",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionUser,
+					Name:        "user",
+					Description: "The user to restore",
 					Required:    true,
-					Choices: []*discordgo.ApplicationCommandOptionChoice{
-						{Name: "Verbal Warning", Value: actionVerbalWarn},
-						{Name: "Bot Warning", Value: actionBotWarn},
-						{Name: "Timeout", Value: actionTimeout},
-						{Name: "Isolate", Value: actionIsolate},
-						{Name: "Kick", Value: actionKick},
-						{Name: "Permanent Ban", Value: actionBan},
-						{Name: "Other", Value: actionOther},
-					},
 				},
 				{
 					Type:        discordgo.ApplicationCommandOptionString,
 					Name:        "reason",
-					Description: "The reason for the action",
+					Description: "The reason for restoring the user",
 					Required:    false,
 				},
 			},
 		},
 		{
-			Name:        cmdLoggingExt,
-			Description: "Log a moderator action by ID. Use log if the user is in the server.",
+			Name:         cmdReply,
+			DMPermission: &cannotDM,
+			Description:  "Reply to the user of this modmail thread",
 			Options: []*discordgo.ApplicationCommandOption{
 				{
 					Type:        discordgo.ApplicationCommandOptionString,
-					Name:        "user",
-					Description: "The user you took action on",
+					Name:        "message",
+					Description: "The message to send",
 					Required:    true,
+					MaxLength:   4000,
 				},
 				{
-					Type:        discordgo.ApplicationCommandOptionString,
-					Name:        "action",
-					Description: "The action you took",
-					Required:    true,
-					Choices: []*discordgo.ApplicationCommandOptionChoice{
-						{Name: "Verbal Warning", Value: actionVerbalWarn},
-						{Name: "Bot Warning", Value: actionBotWarn},
-						{Name: "Timeout", Value: actionTimeout},
-						{Name: "Isolate", Value: actionIsolate},
-						{Name: "Kick", Value: actionKick},
-						{Name: "Permanent Ban", Value: actionBan},
-						{Name: "Other", Value: actionOther},
-					},
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "anonymous",
+					Description: "Hide your name from the user",
+					Required:    false,
 				},
+			},
+		},
+		{
+			Name:         cmdClose,
+			DMPermission: &cannotDM,
+			Description:  "Close this modmail thread and save a transcript",
+			Options: []*discordgo.ApplicationCommandOption{
 				{
 					Type:        discordgo.ApplicationCommandOptionString,
 					Name:        "reason",
-					Description: "The reason for the action",
+					Description: "Why the conversation was closed",
 					Required:    false,
 				},
 			},
 		},
 		{
-			Name:         cmdRestore,
+			Name:         cmdCase,
 			DMPermission: &cannotDM,
-			Description:  "Restores a user's roles",
+			Description:  "View and manage logged moderation cases",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        caseView,
+					Description: "View a case",
+					Options:     []*discordgo.ApplicationCommandOption{caseNumberOption()},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        caseReason,
+					Description: "Change the reason of a case, updating the log message",
+					Options: []*discordgo.ApplicationCommandOption{
+						caseNumberOption(),
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "reason",
+							Description: "The new reason",
+							Required:    true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        caseDelete,
+					Description: "Delete a case",
+					Options:     []*discordgo.ApplicationCommandOption{caseNumberOption()},
+				},
+			},
+		},
+		{
+			Name:         cmdHistory,
+			DMPermission: &cannotDM,
+			Description:  "List a user's logged moderation cases",
 			Options: []*discordgo.ApplicationCommandOption{
 				{
 					Type:        discordgo.ApplicationCommandOptionUser,
 					Name:        "user",
-					Description: "The user to restore",
+					Description: "The user to look up",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Name:         cmdWhois,
+			DMPermission: &cannotDM,
+			Description:  "Show an overview of a user, with buttons to isolate, log or see their history",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionUser,
+					Name:        "user",
+					Description: "The user to look up",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Name:         cmdHistoryMenu,
+			Type:         discordgo.UserApplicationCommand,
+			DMPermission: &cannotDM,
+		},
+		{
+			Name:         cmdTimeout,
+			DMPermission: &cannotDM,
+			Description:  "Time out a member, DM them the reason and log it",
+			Options: []*discordgo.ApplicationCommandOption{
+				moderationUserOption("The member to time out"),
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "duration",
+					Description: "How long (e.g. 10m, 1h, 3d), up to 28 days",
 					Required:    true,
 				},
+				moderationReasonOption(),
+			},
+		},
+		{
+			Name:         cmdKick,
+			DMPermission: &cannotDM,
+			Description:  "Kick a member, DM them the reason and log it",
+			Options: []*discordgo.ApplicationCommandOption{
+				moderationUserOption("The member to kick"),
+				moderationReasonOption(),
+			},
+		},
+		{
+			Name:         cmdBan,
+			DMPermission: &cannotDM,
+			Description:  "Permanently ban a user, DM them the reason and log it",
+			Options: []*discordgo.ApplicationCommandOption{
+				moderationUserOption("The user to ban"),
+				moderationReasonOption(),
+				deleteDaysOption(),
+			},
+		},
+		{
+			Name:         cmdTempBan,
+			DMPermission: &cannotDM,
+			Description:  "Ban a user for a while, DM them the reason and log it",
+			Options: []*discordgo.ApplicationCommandOption{
+				moderationUserOption("The user to ban"),
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "duration",
+					Description: "How long until they're unbanned (e.g. 12h, 3d, 2w)",
+					Required:    true,
+				},
+				moderationReasonOption(),
+				deleteDaysOption(),
+			},
+		},
+		{
+			Name:         cmdUnban,
+			DMPermission: &cannotDM,
+			Description:  "Unban a user and log it",
+			Options: []*discordgo.ApplicationCommandOption{
+				moderationUserOption("The user to unban, paste their ID if needed"),
+				moderationReasonOption(),
+			},
+		},
+		{
+			Name:         cmdPurge,
+			DMPermission: &cannotDM,
+			Description:  "Bulk delete messages in this channel, saving them to the log channel",
+			Options:      purgeOptions(),
+		},
+		{
+			Name:         cmdNote,
+			DMPermission: &cannotDM,
+			Description:  "Staff-only notes on users, which aren't logged as cases",
+			Options:      noteOptions(),
+		},
+		{
+			Name:         cmdRules,
+			DMPermission: &cannotDM,
+			Description:  "The server's rules, and the reason presets suggested when logging",
+			Options:      rulesOptions(),
+		},
+		{
+			Name:         cmdModStats,
+			DMPermission: &cannotDM,
+			Description:  "Show what each moderator logged over a period",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "period",
+					Description: "How far back to look, like 7d, 4w or 90d (default 30d)",
+					Required:    false,
+				},
+			},
+		},
+		{
+			Name:         cmdExport,
+			DMPermission: &cannotDM,
+			Description:  "Export moderation records",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        exportCases,
+					Description: "Download mod cases and current isolations",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "format",
+							Description: "The file format",
+							Required:    true,
+							Choices: []*discordgo.ApplicationCommandOptionChoice{
+								{Name: "CSV", Value: export.FormatCSV},
+								{Name: "JSON", Value: export.FormatJSON},
+							},
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "from",
+							Description: "First day to include, like 2024-01-01",
+							Required:    false,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "to",
+							Description: "Last day to include, like 2024-03-31",
+							Required:    false,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionUser,
+							Name:        "user",
+							Description: "Only cases for this user",
+							Required:    false,
+						},
+						{
+							Type:         discordgo.ApplicationCommandOptionString,
+							Name:         "action",
+							Description:  "Only cases with this action",
+							Required:     false,
+							Autocomplete: true,
+						},
+					},
+				},
 			},
 		},
 	}
@@ -153,7 +430,261 @@ func (b *Bot) registerCommands() error {
 	return nil
 }
 
+// actionChoices are the built-in actions that can be logged with /log and /elog, suggested along with the guild's own
+func actionChoices() []*discordgo.ApplicationCommandOptionChoice {
+	return []*discordgo.ApplicationCommandOptionChoice{
+		{Name: "Verbal Warning", Value: actionVerbalWarn},
+		{Name: "Bot Warning", Value: actionBotWarn},
+		{Name: "Timeout", Value: actionTimeout},
+		{Name: "Isolate", Value: actionIsolate},
+		{Name: "Kick", Value: actionKick},
+		{Name: "Permanent Ban", Value: actionBan},
+		{Name: "Other", Value: actionOther},
+	}
+}
+
+// moderationUserOption is the required target of the moderation commands
+func moderationUserOption(description string) *discordgo.ApplicationCommandOption {
+	return &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionUser,
+		Name:        "user",
+		Description: description,
+		Required:    true,
+	}
+}
+
+// moderationReasonOption is the optional reason of the moderation commands
+func moderationReasonOption() *discordgo.ApplicationCommandOption {
+	return &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionString,
+		Name:        "reason",
+		Description: "The reason, sent to the user and logged",
+		Required:    false,
+	}
+}
+
+// deleteDaysOption lets bans remove the user's recent messages
+func deleteDaysOption() *discordgo.ApplicationCommandOption {
+	minDays := 0.0
+	return &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionInteger,
+		Name:        "delete_days",
+		Description: "Delete their messages from the last few days (0-7)",
+		Required:    false,
+		MinValue:    &minDays,
+		MaxValue:    7,
+	}
+}
+
+// purgeOptions are the count and filters of /purge. The count must stay first.
+func purgeOptions() []*discordgo.ApplicationCommandOption {
+	minCount := 1.0
+	return []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionInteger,
+			Name:        "count",
+			Description: "How many matching messages to delete",
+			Required:    true,
+			MinValue:    &minCount,
+			MaxValue:    500,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionUser,
+			Name:        "user",
+			Description: "Only delete messages from this user",
+			Required:    false,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "contains",
+			Description: "Only delete messages containing this text",
+			Required:    false,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionBoolean,
+			Name:        "bots_only",
+			Description: "Only delete messages from bots",
+			Required:    false,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionBoolean,
+			Name:        "attachments_only",
+			Description: "Only delete messages with attachments",
+			Required:    false,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "before",
+			Description: "Only delete messages before this message ID",
+			Required:    false,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "after",
+			Description: "Only delete messages after this message ID",
+			Required:    false,
+		},
+	}
+}
+
+// noteOptions are the subcommands of /note
+func noteOptions() []*discordgo.ApplicationCommandOption {
+	minID := 1.0
+	return []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        noteAdd,
+			Description: "Add a note on a user",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionUser,
+					Name:        "user",
+					Description: "The user the note is about",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "text",
+					Description: "The note",
+					Required:    true,
+					MaxLength:   maxNoteLength,
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        noteList,
+			Description: "List the notes on a user",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionUser,
+					Name:        "user",
+					Description: "The user to look up",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        noteRemove,
+			Description: "Remove a note",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "id",
+					Description: "The number of the note, see /note list",
+					Required:    true,
+					MinValue:    &minID,
+				},
+			},
+		},
+	}
+}
+
+// rulesOptions are the subcommands of /rules
+func rulesOptions() []*discordgo.ApplicationCommandOption {
+	minOne := 1.0
+	return []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        rulesAdd,
+			Description: "Add a rule, or replace one with the same number",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "text",
+					Description: "The rule, short enough to cite in a reason",
+					Required:    true,
+					MaxLength:   maxRuleLength,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "number",
+					Description: "The rule's number, defaults to after the last rule",
+					Required:    false,
+					MinValue:    &minOne,
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        rulesList,
+			Description: "List the rules and reason presets",
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        rulesRemove,
+			Description: "Remove a rule",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "number",
+					Description: "The rule's number",
+					Required:    true,
+					MinValue:    &minOne,
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+			Name:        rulesPreset,
+			Description: "Saved reasons suggested when logging",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        rulesAdd,
+					Description: "Save a reason to suggest when logging",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "text",
+							Description: "The reason",
+							Required:    true,
+							MaxLength:   maxRuleLength,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "rule",
+							Description: "The rule it falls under, cited at the start of the reason",
+							Required:    false,
+							MinValue:    &minOne,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        rulesRemove,
+					Description: "Remove a reason preset",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "id",
+							Description: "The number of the preset, see /rules list",
+							Required:    true,
+							MinValue:    &minOne,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// caseNumberOption is the required case number option shared by the /case subcommands
+func caseNumberOption() *discordgo.ApplicationCommandOption {
+	minCase := 1.0
+	return &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionInteger,
+		Name:        "number",
+		Description: "The case number",
+		Required:    true,
+		MinValue:    &minCase,
+	}
+}
+
 func (b *Bot) getConfigSubcommands() []*discordgo.ApplicationCommandOption {
+	minZero, minOne := 0.0, 1.0
+
 	commandChoices := make([]*discordgo.ApplicationCommandOptionChoice, 0, len(b.registeredCommands))
 	for name := range b.registeredCommands {
 		commandChoices = append(commandChoices, &discordgo.ApplicationCommandOptionChoice{
@@ -184,7 +715,7 @@ func (b *Bot) getConfigSubcommands() []*discordgo.ApplicationCommandOption {
 		{
 			Type:        discordgo.ApplicationCommandOptionSubCommand,
 			Name:        commands.SetLogChannel,
-			Description: "Set the log channel for the guild",
+			Description: "Set the log channel for the guild, or for one kind of log",
 			Options: []*discordgo.ApplicationCommandOption{
 				{
 					Type:        discordgo.ApplicationCommandOptionChannel,
@@ -192,6 +723,236 @@ func (b *Bot) getConfigSubcommands() []*discordgo.ApplicationCommandOption {
 					Description: "The channel to use for logging",
 					Required:    true,
 				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "category",
+					Description: "The kind of log to send there, defaults to the main log channel",
+					Required:    false,
+					Choices:     logCategoryChoices(),
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        commands.SetAppealsChannel,
+			Description: "Set the channel where isolation appeals are posted for review",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:         discordgo.ApplicationCommandOptionChannel,
+					Name:         "channel",
+					Description:  "The channel to post appeals in",
+					Required:     true,
+					ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        commands.SetModmailChannel,
+			Description: "Set the channel where modmail threads are opened",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:         discordgo.ApplicationCommandOptionChannel,
+					Name:         "channel",
+					Description:  "The channel to open modmail threads in",
+					Required:     true,
+					ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        commands.SetAppealMessageName,
+			Description: "Set the message DMed to isolated users, explaining how to appeal",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "message",
+					Description: "Supports {user}, {guild}, {moderator}, {reason} and {duration}",
+					Required:    true,
+					MaxLength:   1000,
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        commands.ViewPointsName,
+			Description: "View warning point weights, escalation thresholds and decay",
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        commands.SetPointsName,
+			Description: "Set how many warning points an action adds",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:         discordgo.ApplicationCommandOptionString,
+					Name:         "action",
+					Description:  "The logged action",
+					Required:     true,
+					Autocomplete: true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "points",
+					Description: "Points added when the action is logged",
+					Required:    true,
+					MinValue:    &minZero,
+					MaxValue:    100,
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        commands.AddThresholdName,
+			Description: "Automatically act when a member reaches a number of points",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "points",
+					Description: "Active points needed to trigger the action",
+					Required:    true,
+					MinValue:    &minOne,
+					MaxValue:    1000,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "action",
+					Description: "The action to apply",
+					Required:    true,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "Timeout", Value: actionTimeout},
+						{Name: "Isolate", Value: actionIsolate},
+						{Name: "Kick", Value: actionKick},
+						{Name: "Permanent Ban", Value: actionBan},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "duration",
+					Description: "Timeout length (e.g. 1h, 3d), up to 28 days",
+					Required:    false,
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        commands.RemoveThresholdName,
+			Description: "Remove an escalation threshold",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "points",
+					Description: "The points of the threshold to remove",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        commands.SetPointDecayName,
+			Description: "Set how many days logged cases count towards points (0 = forever)",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "days",
+					Description: "Days until points expire, 0 to never expire",
+					Required:    true,
+					MinValue:    &minZero,
+					MaxValue:    3650,
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        commands.CaseThreadsName,
+			Description: "Open a discussion thread on each mod log entry",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "enabled",
+					Description: "Whether threads are opened",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "archive",
+					Description: "How long a thread stays open without activity, defaults to 1 day",
+					Required:    false,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "1 hour", Value: 60},
+						{Name: "1 day", Value: 1440},
+						{Name: "3 days", Value: 4320},
+						{Name: "1 week", Value: 10080},
+					},
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        commands.AuditLogName,
+			Description: "View recent changes to the bot's configuration",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "page",
+					Description: "The page to view, newest changes first",
+					Required:    false,
+					MinValue:    &minOne,
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+			Name:        commands.ActionsGroupName,
+			Description: "Manage the server's own action types for logging",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        commands.ActionsAddName,
+					Description: "Add or update an action type",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "label",
+							Description: "The name moderators see, like Spam Warning",
+							Required:    true,
+							MaxLength:   50,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "color",
+							Description: "Hex color of its log messages, like #FF8800",
+							Required:    false,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "points",
+							Description: "Warning points added when it's logged",
+							Required:    false,
+							MinValue:    &minZero,
+							MaxValue:    100,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        commands.ActionsRemoveName,
+					Description: "Remove an action type, keeping cases already logged with it",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionString,
+							Name:         "action",
+							Description:  "The action to remove",
+							Required:     true,
+							Autocomplete: true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        commands.ActionsListName,
+					Description: "List the server's own action types",
+				},
 			},
 		},
 		{
@@ -265,3 +1026,15 @@ func (b *Bot) RefreshCommands() error {
 
 	return nil
 }
+
+// logCategoryChoices lists the log categories for /config setlogchannel
+func logCategoryChoices() []*discordgo.ApplicationCommandOptionChoice {
+	choices := []*discordgo.ApplicationCommandOptionChoice{}
+	for _, c := range logging.Categories {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  c.Label,
+			Value: string(c.Category),
+		})
+	}
+	return choices
+}
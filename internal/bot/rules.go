@@ -0,0 +1,224 @@
+// This file handles /rules, the server's numbered rules and the reason presets suggested when logging
+package bot
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/shininglegend/shieldbot/internal/cases"
+	"github.com/shininglegend/shieldbot/pkg/auth"
+	"github.com/shininglegend/shieldbot/pkg/utils"
+)
+
+const (
+	// Subcommands of /rules, and of its preset group
+	rulesAdd    = "add"
+	rulesList   = "list"
+	rulesRemove = "remove"
+	rulesPreset = "preset"
+
+	maxRuleLength = 200
+)
+
+func (b *Bot) handleRules(s *discordgo.Session, i *discordgo.InteractionCreate) *discordgo.MessageEmbed {
+	subcommand := i.ApplicationCommandData().Options[0]
+	options := subcommand.Options
+
+	// Anyone can read the rules, only admins can change them
+	if subcommand.Name != rulesList {
+		if e := auth.QuickAuthAdminOrOverride(b.pm, s, i); e != nil {
+			return e
+		}
+	}
+
+	switch subcommand.Name {
+	case rulesAdd:
+		r := &cases.Rule{
+			GuildID: i.GuildID,
+			Text:    strings.TrimSpace(utils.FindOption(options, "text").StringValue()),
+		}
+		if opt := utils.FindOption(options, "number"); opt != nil {
+			r.Number = int(opt.IntValue())
+		}
+		if r.Text == "" {
+			return utils.CreateNotAllowedEmbed("Empty rule", "The rule can't be empty.")
+		}
+		err := b.cm.AddRule(r)
+		if err != nil {
+			return utils.CreateErrorEmbed(s, i, "Failed to add rule", err)
+		}
+		return utils.CreateEmbed("Rule Saved", fmt.Sprintf("**Rule %v**\n%v", r.Number, r.Text))
+
+	case rulesList:
+		return b.rulesEmbed(s, i)
+
+	case rulesRemove:
+		number := int(utils.FindOption(options, "number").IntValue())
+		err := b.cm.RemoveRule(i.GuildID, number)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return utils.CreateNotAllowedEmbed("Rule not found", fmt.Sprintf("There is no rule %v in this server.", number))
+			}
+			return utils.CreateErrorEmbed(s, i, "Failed to remove rule", err)
+		}
+		return utils.CreateEmbed("Rule Removed", fmt.Sprintf("Removed rule %v. Cases logged under it still cite it.", number))
+
+	case rulesPreset:
+		return b.handleRulesPreset(s, i, options[0])
+
+	default:
+		return utils.CreateNotAllowedEmbed("Unknown subcommand to rules", fmt.Sprintf("Unknown subcommand: %v", subcommand.Name))
+	}
+}
+
+// handleRulesPreset handles /rules preset add and remove
+func (b *Bot) handleRulesPreset(s *discordgo.Session, i *discordgo.InteractionCreate, subcommand *discordgo.ApplicationCommandInteractionDataOption) *discordgo.MessageEmbed {
+	options := subcommand.Options
+
+	switch subcommand.Name {
+	case rulesAdd:
+		p := &cases.Preset{
+			GuildID: i.GuildID,
+			Text:    strings.TrimSpace(utils.FindOption(options, "text").StringValue()),
+		}
+		if opt := utils.FindOption(options, "rule"); opt != nil {
+			p.Rule = int(opt.IntValue())
+		}
+		if p.Text == "" {
+			return utils.CreateNotAllowedEmbed("Empty preset", "The preset can't be empty.")
+		}
+		err := b.cm.AddPreset(p)
+		if err != nil {
+			return utils.CreateErrorEmbed(s, i, "Failed to add preset", err)
+		}
+		return utils.CreateEmbed("Preset Added", fmt.Sprintf("Preset #%v: %v", p.ID, p.Reason()))
+
+	case rulesRemove:
+		id := utils.FindOption(options, "id").IntValue()
+		err := b.cm.RemovePreset(i.GuildID, id)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return utils.CreateNotAllowedEmbed("Preset not found", fmt.Sprintf("There is no preset #%v in this server.", id))
+			}
+			return utils.CreateErrorEmbed(s, i, "Failed to remove preset", err)
+		}
+		return utils.CreateEmbed("Preset Removed", fmt.Sprintf("Removed preset #%v.", id))
+
+	default:
+		return utils.CreateNotAllowedEmbed("Unknown subcommand to rules preset", fmt.Sprintf("Unknown subcommand: %v", subcommand.Name))
+	}
+}
+
+// rulesEmbed lists the guild's rules, followed by its reason presets
+func (b *Bot) rulesEmbed(s *discordgo.Session, i *discordgo.InteractionCreate) *discordgo.MessageEmbed {
+	rules, err := b.cm.GetRules(i.GuildID)
+	if err != nil {
+		return utils.CreateErrorEmbed(s, i, "Failed to fetch rules", err)
+	}
+	presets, err := b.cm.GetPresets(i.GuildID)
+	if err != nil {
+		return utils.CreateErrorEmbed(s, i, "Failed to fetch presets", err)
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: "Server Rules",
+		Color: 0x0000FF, // Blue
+	}
+	if len(rules) == 0 {
+		embed.Description = "No rules have been added, see /rules add."
+	} else {
+		var lines []string
+		for _, r := range rules {
+			lines = append(lines, fmt.Sprintf("**%v.** %v", r.Number, r.Text))
+		}
+		embed.Description = truncate(strings.Join(lines, "\n"), 4096)
+	}
+	if len(presets) > 0 {
+		var lines []string
+		for _, p := range presets {
+			lines = append(lines, fmt.Sprintf("`#%v` %v", p.ID, p.Reason()))
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  "Reason Presets",
+			Value: truncate(strings.Join(lines, "\n"), 1024),
+		})
+	}
+	return embed
+}
+
+// reasonAutocomplete suggests the rules and presets matching what was typed, so cases cite the rule they're for
+func (b *Bot) reasonAutocomplete(guildID, typed string) []*discordgo.ApplicationCommandOptionChoice {
+	var reasons []string
+	rules, err := b.cm.GetRules(guildID)
+	if err != nil {
+		log.Printf("Error fetching rules: %v", err)
+	}
+	for _, r := range rules {
+		reasons = append(reasons, r.Reason())
+	}
+	presets, err := b.cm.GetPresets(guildID)
+	if err != nil {
+		log.Printf("Error fetching presets: %v", err)
+	}
+	for _, p := range presets {
+		reasons = append(reasons, p.Reason())
+	}
+
+	typed = strings.ToLower(strings.TrimSpace(typed))
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, 0, maxAutocompleteChoices)
+	for _, reason := range reasons {
+		if len(choices) == maxAutocompleteChoices {
+			break
+		}
+		if strings.Contains(strings.ToLower(reason), typed) {
+			// Choices are limited to 100 characters
+			reason = truncate(reason, 100)
+			choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: reason, Value: reason})
+		}
+	}
+	return choices
+}
+
+// historyRules counts a user's cases by the rule they cite, or returns nil if none cite one
+func (b *Bot) historyRules(guildID string, userCases []*cases.Case) *discordgo.MessageEmbedField {
+	counts := make(map[int]int)
+	for _, c := range userCases {
+		if c.Rule != 0 {
+			counts[c.Rule]++
+		}
+	}
+	if len(counts) == 0 {
+		return nil
+	}
+
+	rules, err := b.cm.GetRules(guildID)
+	if err != nil {
+		log.Printf("Error fetching rules: %v", err)
+	}
+	text := make(map[int]string)
+	for _, r := range rules {
+		text[r.Number] = r.Text
+	}
+	numbers := make([]int, 0, len(counts))
+	for number := range counts {
+		numbers = append(numbers, number)
+	}
+	sort.Ints(numbers)
+
+	var lines []string
+	for _, number := range numbers {
+		line := fmt.Sprintf("Rule %v: %v", number, counts[number])
+		if t, ok := text[number]; ok {
+			line = fmt.Sprintf("Rule %v (%v): %v", number, truncate(t, 50), counts[number])
+		}
+		lines = append(lines, line)
+	}
+	return &discordgo.MessageEmbedField{
+		Name:  "By Rule",
+		Value: truncate(strings.Join(lines, "\n"), 1024),
+	}
+}
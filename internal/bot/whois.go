@@ -0,0 +1,305 @@
+// This file handles /whois, an overview of a user with buttons for the usual next steps
+package bot
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/shininglegend/shieldbot/pkg/auth"
+	"github.com/shininglegend/shieldbot/pkg/utils"
+)
+
+// Custom ID of the text inputs in the isolate form
+const (
+	inputIsolateReason   = "reason"
+	inputIsolateDuration = "duration"
+)
+
+func (b *Bot) handleWhois(s *discordgo.Session, i *discordgo.InteractionCreate) (*discordgo.MessageEmbed, []discordgo.MessageComponent) {
+	if e := auth.QuickAuthManageMessagesOrOverride(b.pm, s, i); e != nil {
+		return e, nil
+	}
+	user := i.ApplicationCommandData().Options[0].UserValue(s)
+	if user == nil {
+		return utils.CreateNotAllowedEmbed("User not found", "Could not find that user."), nil
+	}
+
+	// They may have left, or never joined
+	member, err := s.GuildMember(i.GuildID, user.ID)
+	if err != nil {
+		member = nil
+	}
+
+	var savedRoles string
+	isolated := true
+	err = b.db.QueryRow("SELECT roles FROM user_roles WHERE user_id = ? AND guild_id = ?", user.ID, i.GuildID).Scan(&savedRoles)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			return utils.CreateErrorEmbed(s, i, "Failed to fetch roles", err), nil
+		}
+		isolated = false
+	}
+	userCases, err := b.cm.GetUserCases(i.GuildID, user.ID)
+	if err != nil {
+		return utils.CreateErrorEmbed(s, i, "Failed to fetch history", err), nil
+	}
+	notes, err := b.cm.GetNotes(i.GuildID, user.ID)
+	if err != nil {
+		return utils.CreateErrorEmbed(s, i, "Failed to fetch notes", err), nil
+	}
+
+	created, err := discordgo.SnowflakeTimestamp(user.ID)
+	if err != nil {
+		log.Printf("Error reading account age: %v", err)
+	}
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("Who is %v", user.Username),
+		Description: fmt.Sprintf("%v `%v`", user.Mention(), user.ID),
+		Color:       0x0000FF, // Blue
+		Thumbnail:   &discordgo.MessageEmbedThumbnail{URL: user.AvatarURL("")},
+		Fields: []*discordgo.MessageEmbedField{
+			{
+				Name:   "Account Created",
+				Value:  fmt.Sprintf("<t:%v:f> (<t:%v:R>)", created.Unix(), created.Unix()),
+				Inline: true,
+			},
+		},
+	}
+
+	if member != nil {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   "Joined",
+			Value:  fmt.Sprintf("<t:%v:f> (<t:%v:R>)", member.JoinedAt.Unix(), member.JoinedAt.Unix()),
+			Inline: true,
+		})
+		timeout := "No"
+		if member.CommunicationDisabledUntil != nil && member.CommunicationDisabledUntil.After(time.Now()) {
+			timeout = fmt.Sprintf("Until <t:%v:f> (<t:%v:R>)", member.CommunicationDisabledUntil.Unix(), member.CommunicationDisabledUntil.Unix())
+		}
+		embed.Fields = append(embed.Fields,
+			&discordgo.MessageEmbedField{
+				Name:   "Timed Out",
+				Value:  timeout,
+				Inline: true,
+			},
+			&discordgo.MessageEmbedField{
+				Name:  "Roles",
+				Value: roleMentions(member.Roles),
+			},
+		)
+	} else {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   "Joined",
+			Value:  "Not in the server",
+			Inline: true,
+		})
+	}
+
+	isolatedValue := "No"
+	if isolated {
+		isolatedValue = "Yes, with these roles saved to restore:\n" + roleMentions(strings.Split(savedRoles, ","))
+	}
+	embed.Fields = append(embed.Fields,
+		&discordgo.MessageEmbedField{
+			Name:  "Isolated",
+			Value: truncate(isolatedValue, 1024),
+		},
+		&discordgo.MessageEmbedField{
+			Name:   "Cases",
+			Value:  fmt.Sprint(len(userCases)),
+			Inline: true,
+		},
+		&discordgo.MessageEmbedField{
+			Name:   "Notes",
+			Value:  fmt.Sprint(len(notes)),
+			Inline: true,
+		},
+	)
+
+	return embed, []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{
+				Label:    "Isolate",
+				Style:    discordgo.DangerButton,
+				CustomID: makeCustomID(compWhoisIsolate, user.ID),
+				Disabled: member == nil || isolated,
+			},
+			discordgo.Button{
+				Label:    "Log",
+				Style:    discordgo.PrimaryButton,
+				CustomID: makeCustomID(compWhoisLog, user.ID),
+			},
+			discordgo.Button{
+				Label:    "History",
+				Style:    discordgo.SecondaryButton,
+				CustomID: makeCustomID(compWhoisHistory, user.ID),
+			},
+		}},
+	}
+}
+
+// handleWhoisIsolate opens a form for the reason and duration of an isolation from /whois
+func (b *Bot) handleWhoisIsolate(s *discordgo.Session, i *discordgo.InteractionCreate, args []string) {
+	if e := auth.QuickAuthManageRolesOrOverrideFor(b.pm, s, i, cmdIsolate); e != nil {
+		respondEphemeral(s, i, e)
+		return
+	}
+	user, err := s.User(args[0])
+	if err != nil {
+		respondEphemeral(s, i, utils.CreateErrorEmbed(s, i, "Failed to fetch user", err))
+		return
+	}
+
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID: makeCustomID(compWhoisIsolateSubmit, user.ID),
+			Title:    truncate(fmt.Sprintf("Isolate %v", user.Username), 45),
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					discordgo.TextInput{
+						CustomID:  inputIsolateReason,
+						Label:     "Reason",
+						Style:     discordgo.TextInputShort,
+						Required:  false,
+						MaxLength: 512,
+					},
+				}},
+				discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					discordgo.TextInput{
+						CustomID:    inputIsolateDuration,
+						Label:       "Duration, after which they're restored",
+						Style:       discordgo.TextInputShort,
+						Placeholder: "e.g. 12h or 3d, leave empty for until further notice",
+						Required:    false,
+						MaxLength:   20,
+					},
+				}},
+			},
+		},
+	})
+	if err != nil {
+		log.Printf("Error opening isolate form: %v", err)
+	}
+}
+
+// handleWhoisIsolateSubmit isolates the user from a submitted isolate form
+func (b *Bot) handleWhoisIsolateSubmit(s *discordgo.Session, i *discordgo.InteractionCreate, args []string) {
+	if e := auth.QuickAuthManageRolesOrOverrideFor(b.pm, s, i, cmdIsolate); e != nil {
+		respondEphemeral(s, i, e)
+		return
+	}
+	// Isolating takes a few requests, so acknowledge first
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		log.Printf("Error acknowledging interaction: %v", err)
+		return
+	}
+
+	var embed *discordgo.MessageEmbed
+	user, err := s.User(args[0])
+	if err != nil {
+		embed = utils.CreateErrorEmbed(s, i, "Failed to fetch user", err)
+	} else {
+		embed = b.isolateAndLog(s, i, user, strings.TrimSpace(modalValue(i, inputIsolateReason)), modalValue(i, inputIsolateDuration))
+	}
+	b.editResponseEmbed(s, i, true, embed, nil, nil)
+}
+
+// handleWhoisLog asks which action to log from /whois
+func (b *Bot) handleWhoisLog(s *discordgo.Session, i *discordgo.InteractionCreate, args []string) {
+	if e := auth.QuickAuthManageMessagesOrOverrideFor(b.pm, s, i, cmdLogging); e != nil {
+		respondEphemeral(s, i, e)
+		return
+	}
+	var options []discordgo.SelectMenuOption
+	for _, choice := range b.actionAutocomplete(i.GuildID, "", false) {
+		options = append(options, discordgo.SelectMenuOption{
+			Label: choice.Name,
+			Value: choice.Value.(string),
+		})
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: fmt.Sprintf("What do you want to log on <@%v>? The bot will only log it, not perform it.", args[0]),
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					discordgo.SelectMenu{
+						CustomID:    makeCustomID(compWhoisLogAction, args[0]),
+						Placeholder: "Choose an action",
+						Options:     options,
+					},
+				}},
+			},
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		log.Printf("Error responding to interaction: %v", err)
+	}
+}
+
+// handleWhoisLogAction opens the log form for the chosen action
+func (b *Bot) handleWhoisLogAction(s *discordgo.Session, i *discordgo.InteractionCreate, args []string) {
+	if e := auth.QuickAuthManageMessagesOrOverrideFor(b.pm, s, i, cmdLogging); e != nil {
+		respondEphemeral(s, i, e)
+		return
+	}
+	user, err := s.User(args[0])
+	if err != nil {
+		respondEphemeral(s, i, utils.CreateErrorEmbed(s, i, "Failed to fetch user", err))
+		return
+	}
+	b.openLogForm(s, i, user, i.MessageComponentData().Values[0], "0", "", "")
+}
+
+// handleWhoisHistory shows the user's history from /whois
+func (b *Bot) handleWhoisHistory(s *discordgo.Session, i *discordgo.InteractionCreate, args []string) {
+	if e := auth.QuickAuthManageMessagesOrOverrideFor(b.pm, s, i, cmdHistory); e != nil {
+		respondEphemeral(s, i, e)
+		return
+	}
+	user, err := s.User(args[0])
+	if err != nil {
+		respondEphemeral(s, i, utils.CreateErrorEmbed(s, i, "Failed to fetch user", err))
+		return
+	}
+
+	embed, components := b.historyPage(s, i, user, 0)
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: components,
+			Flags:      discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		log.Printf("Error showing history: %v", err)
+	}
+}
+
+// roleMentions lists roles for an embed field
+func roleMentions(roleIDs []string) string {
+	var roles []string
+	for _, roleID := range roleIDs {
+		if roleID != "" {
+			roles = append(roles, fmt.Sprintf("<@&%v>", roleID))
+		}
+	}
+	if len(roles) == 0 {
+		return "None"
+	}
+	return truncate(strings.Join(roles, " "), 1024)
+}
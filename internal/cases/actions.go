@@ -0,0 +1,84 @@
+// internal/cases/actions.go
+package cases
+
+import (
+	"database/sql"
+)
+
+// ActionType is an action a guild defined for logging, on top of the built-in ones.
+// Its point weight is stored with the other weights, see SetActionPoints.
+type ActionType struct {
+	Name  string // What's stored in cases
+	Label string // What moderators see
+	Color int
+}
+
+// AddActionType adds or replaces a custom action type, along with its point weight
+func (cm *CaseManager) AddActionType(guildID string, a ActionType, points int) error {
+	tx, err := cm.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO custom_actions (guild_id, name, label, color)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(guild_id, name) DO UPDATE SET label = ?, color = ?`,
+		guildID, a.Name, a.Label, a.Color, a.Label, a.Color)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`
+		INSERT INTO action_points (guild_id, action, points)
+		VALUES (?, ?, ?)
+		ON CONFLICT(guild_id, action) DO UPDATE SET points = ?`,
+		guildID, a.Name, points, points)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RemoveActionType deletes a custom action type, returning sql.ErrNoRows if there wasn't one.
+// Cases already logged with it are kept.
+func (cm *CaseManager) RemoveActionType(guildID, name string) error {
+	result, err := cm.db.Exec("DELETE FROM custom_actions WHERE guild_id = ? AND name = ?", guildID, name)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return sql.ErrNoRows
+	}
+	_, err = cm.db.Exec("DELETE FROM action_points WHERE guild_id = ? AND action = ?", guildID, name)
+	return err
+}
+
+// GetActionType returns a custom action type, or sql.ErrNoRows if the guild doesn't have it
+func (cm *CaseManager) GetActionType(guildID, name string) (*ActionType, error) {
+	a := &ActionType{}
+	err := cm.db.QueryRow("SELECT name, label, color FROM custom_actions WHERE guild_id = ? AND name = ?", guildID, name).Scan(&a.Name, &a.Label, &a.Color)
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// GetActionTypes returns the guild's custom action types, sorted by label
+func (cm *CaseManager) GetActionTypes(guildID string) ([]ActionType, error) {
+	rows, err := cm.db.Query("SELECT name, label, color FROM custom_actions WHERE guild_id = ? ORDER BY label", guildID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var actions []ActionType
+	for rows.Next() {
+		var a ActionType
+		if err := rows.Scan(&a.Name, &a.Label, &a.Color); err != nil {
+			return nil, err
+		}
+		actions = append(actions, a)
+	}
+	return actions, rows.Err()
+}
@@ -0,0 +1,49 @@
+// internal/cases/attachments.go
+package cases
+
+// Attachment is a piece of evidence uploaded with a case
+type Attachment struct {
+	Filename    string
+	URL         string // Where it was re-uploaded in the mod log, or the link given as evidence
+	ContentType string
+	Size        int // 0 for links
+}
+
+// AddAttachments records the evidence uploaded with a case
+func (cm *CaseManager) AddAttachments(guildID string, number int, attachments []Attachment) error {
+	tx, err := cm.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, a := range attachments {
+		_, err = tx.Exec(`
+			INSERT INTO case_attachments (guild_id, case_number, filename, url, content_type, size)
+			VALUES (?, ?, ?, ?, ?, ?)`,
+			guildID, number, a.Filename, a.URL, a.ContentType, a.Size)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetAttachments returns the evidence of a case, in the order it was uploaded
+func (cm *CaseManager) GetAttachments(guildID string, number int) ([]Attachment, error) {
+	rows, err := cm.db.Query("SELECT filename, url, content_type, size FROM case_attachments WHERE guild_id = ? AND case_number = ? ORDER BY rowid", guildID, number)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attachments []Attachment
+	for rows.Next() {
+		var a Attachment
+		if err := rows.Scan(&a.Filename, &a.URL, &a.ContentType, &a.Size); err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, a)
+	}
+	return attachments, rows.Err()
+}
@@ -0,0 +1,330 @@
+// internal/cases/cases.go
+package cases
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Case is a single logged moderator action
+type Case struct {
+	GuildID      string
+	Number       int
+	ModeratorID  string
+	TargetID     string
+	Action       string
+	Reason       string
+	CreatedAt    time.Time
+	LogChannelID string
+	LogMessageID string
+	Points       int    // Weight of the action when it was logged
+	LinkedCase   int    // For automatic cases, the case that caused them. 0 if none.
+	Details      string // Longer explanation, given through the log form
+	ThreadID     string // Discussion thread on the log message. Empty if none.
+	Rule         int    // The rule the reason cites, set from it when stored. 0 if none.
+
+	// Not loaded with the case, see GetAttachments
+	Attachments []Attachment
+}
+
+// caseColumns are the columns read by scanCase, in order
+const caseColumns = "guild_id, case_number, moderator_id, target_id, action, reason, created_at, log_channel_id, log_message_id, points, linked_case, details, thread_id, rule"
+
+// scanner is implemented by both *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanCase(row scanner) (*Case, error) {
+	c := &Case{}
+	var createdAt int64
+	err := row.Scan(&c.GuildID, &c.Number, &c.ModeratorID, &c.TargetID, &c.Action, &c.Reason, &createdAt, &c.LogChannelID, &c.LogMessageID, &c.Points, &c.LinkedCase, &c.Details, &c.ThreadID, &c.Rule)
+	if err != nil {
+		return nil, err
+	}
+	c.CreatedAt = time.Unix(createdAt, 0)
+	return c, nil
+}
+
+type CaseManager struct {
+	db *sql.DB
+}
+
+func NewCaseManager(db *sql.DB) *CaseManager {
+	return &CaseManager{db: db}
+}
+
+// CreateCase stores a new case, assigning it the next case number for the guild
+func (cm *CaseManager) CreateCase(c *Case) error {
+	tx, err := cm.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Numbers come from a counter so deleted cases are never reused
+	_, err = tx.Exec(`
+		INSERT INTO case_counters (guild_id, last_case)
+		VALUES (?, 1)
+		ON CONFLICT(guild_id) DO UPDATE SET last_case = last_case + 1`,
+		c.GuildID)
+	if err != nil {
+		return err
+	}
+	var number int
+	err = tx.QueryRow("SELECT last_case FROM case_counters WHERE guild_id = ?", c.GuildID).Scan(&number)
+	if err != nil {
+		return err
+	}
+
+	c.Rule = RuleFromReason(c.Reason)
+	_, err = tx.Exec(`
+		INSERT INTO mod_cases (guild_id, case_number, moderator_id, target_id, action, reason, created_at, log_channel_id, log_message_id, points, linked_case, details, thread_id, rule)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		c.GuildID, number, c.ModeratorID, c.TargetID, c.Action, c.Reason, c.CreatedAt.Unix(), c.LogChannelID, c.LogMessageID, c.Points, c.LinkedCase, c.Details, c.ThreadID, c.Rule)
+	if err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	c.Number = number
+	return nil
+}
+
+// SetLogMessage records where the case was posted
+func (cm *CaseManager) SetLogMessage(guildID string, number int, channelID, messageID string) error {
+	_, err := cm.db.Exec("UPDATE mod_cases SET log_channel_id = ?, log_message_id = ? WHERE guild_id = ? AND case_number = ?",
+		channelID, messageID, guildID, number)
+	return err
+}
+
+// SetThread records the discussion thread of a case
+func (cm *CaseManager) SetThread(guildID string, number int, threadID string) error {
+	_, err := cm.db.Exec("UPDATE mod_cases SET thread_id = ? WHERE guild_id = ? AND case_number = ?", threadID, guildID, number)
+	return err
+}
+
+// GetCase returns a case, or sql.ErrNoRows if it doesn't exist
+func (cm *CaseManager) GetCase(guildID string, number int) (*Case, error) {
+	row := cm.db.QueryRow("SELECT "+caseColumns+" FROM mod_cases WHERE guild_id = ? AND case_number = ?", guildID, number)
+	return scanCase(row)
+}
+
+// GetUserCases returns every case for a user in a guild, newest first
+func (cm *CaseManager) GetUserCases(guildID, userID string) ([]*Case, error) {
+	rows, err := cm.db.Query("SELECT "+caseColumns+" FROM mod_cases WHERE guild_id = ? AND target_id = ? ORDER BY case_number DESC", guildID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []*Case
+	for rows.Next() {
+		c, err := scanCase(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, c)
+	}
+	return result, rows.Err()
+}
+
+// Filter narrows down SearchCases. Empty fields match everything.
+type Filter struct {
+	GuildID string
+	UserID  string // The target
+	Action  string
+	From    time.Time // Inclusive
+	To      time.Time // Exclusive
+}
+
+// SearchCases returns the cases matching the filter, by guild and then oldest first
+func (cm *CaseManager) SearchCases(f Filter) ([]*Case, error) {
+	var conditions []string
+	var args []any
+	if f.GuildID != "" {
+		conditions = append(conditions, "guild_id = ?")
+		args = append(args, f.GuildID)
+	}
+	if f.UserID != "" {
+		conditions = append(conditions, "target_id = ?")
+		args = append(args, f.UserID)
+	}
+	if f.Action != "" {
+		conditions = append(conditions, "action = ?")
+		args = append(args, f.Action)
+	}
+	if !f.From.IsZero() {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, f.From.Unix())
+	}
+	if !f.To.IsZero() {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, f.To.Unix())
+	}
+	query := "SELECT " + caseColumns + " FROM mod_cases"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY guild_id, case_number"
+
+	rows, err := cm.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []*Case
+	for rows.Next() {
+		c, err := scanCase(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, c)
+	}
+	return result, rows.Err()
+}
+
+// UpdateReason changes the reason of a case, along with the rule it cites
+func (cm *CaseManager) UpdateReason(guildID string, number int, reason string) error {
+	_, err := cm.db.Exec("UPDATE mod_cases SET reason = ?, rule = ? WHERE guild_id = ? AND case_number = ?", reason, RuleFromReason(reason), guildID, number)
+	return err
+}
+
+func (cm *CaseManager) DeleteCase(guildID string, number int) error {
+	_, err := cm.db.Exec("DELETE FROM mod_cases WHERE guild_id = ? AND case_number = ?", guildID, number)
+	if err != nil {
+		return err
+	}
+	_, err = cm.db.Exec("DELETE FROM case_attachments WHERE guild_id = ? AND case_number = ?", guildID, number)
+	return err
+}
+
+func (cm *CaseManager) SetupTables() error {
+	queries := []string{
+		`CREATE TABLE IF NOT EXISTS mod_cases (
+			guild_id TEXT,
+			case_number INTEGER,
+			moderator_id TEXT,
+			target_id TEXT,
+			action TEXT,
+			reason TEXT,
+			created_at INTEGER,
+			log_channel_id TEXT,
+			log_message_id TEXT,
+			PRIMARY KEY (guild_id, case_number)
+		)`,
+		`CREATE INDEX IF NOT EXISTS mod_cases_target ON mod_cases (guild_id, target_id)`,
+		`CREATE TABLE IF NOT EXISTS case_counters (
+			guild_id TEXT PRIMARY KEY,
+			last_case INTEGER
+		)`,
+		`CREATE TABLE IF NOT EXISTS case_attachments (
+			guild_id TEXT,
+			case_number INTEGER,
+			filename TEXT,
+			url TEXT,
+			content_type TEXT,
+			size INTEGER
+		)`,
+		`CREATE INDEX IF NOT EXISTS case_attachments_case ON case_attachments (guild_id, case_number)`,
+		`CREATE TABLE IF NOT EXISTS action_points (
+			guild_id TEXT,
+			action TEXT,
+			points INTEGER,
+			PRIMARY KEY (guild_id, action)
+		)`,
+		`CREATE TABLE IF NOT EXISTS custom_actions (
+			guild_id TEXT,
+			name TEXT,
+			label TEXT,
+			color INTEGER,
+			PRIMARY KEY (guild_id, name)
+		)`,
+		`CREATE TABLE IF NOT EXISTS temp_bans (
+			guild_id TEXT,
+			user_id TEXT,
+			expires_at INTEGER,
+			case_number INTEGER,
+			PRIMARY KEY (guild_id, user_id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS temp_isolations (
+			guild_id TEXT,
+			user_id TEXT,
+			expires_at INTEGER,
+			case_number INTEGER,
+			PRIMARY KEY (guild_id, user_id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS user_notes (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			guild_id TEXT,
+			user_id TEXT,
+			author_id TEXT,
+			text TEXT,
+			created_at INTEGER
+		)`,
+		`CREATE INDEX IF NOT EXISTS user_notes_user ON user_notes (guild_id, user_id)`,
+		`CREATE TABLE IF NOT EXISTS user_log_posts (
+			guild_id TEXT,
+			forum_id TEXT,
+			user_id TEXT,
+			thread_id TEXT,
+			PRIMARY KEY (guild_id, forum_id, user_id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS server_rules (
+			guild_id TEXT,
+			number INTEGER,
+			text TEXT,
+			PRIMARY KEY (guild_id, number)
+		)`,
+		`CREATE TABLE IF NOT EXISTS reason_presets (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			guild_id TEXT,
+			rule INTEGER,
+			text TEXT
+		)`,
+		`CREATE INDEX IF NOT EXISTS reason_presets_guild ON reason_presets (guild_id)`,
+		`CREATE TABLE IF NOT EXISTS escalation_thresholds (
+			guild_id TEXT,
+			points INTEGER,
+			action TEXT,
+			duration_seconds INTEGER,
+			PRIMARY KEY (guild_id, points)
+		)`,
+	}
+
+	for _, query := range queries {
+		_, err := cm.db.Exec(query)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Columns added after mod_cases was first created
+	columns := []string{
+		"points INTEGER DEFAULT 0",
+		"linked_case INTEGER DEFAULT 0",
+		"details TEXT DEFAULT ''",
+		"thread_id TEXT DEFAULT ''",
+		"rule INTEGER DEFAULT 0",
+	}
+	for _, column := range columns {
+		err := cm.addColumn("mod_cases", column)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addColumn adds a column to an existing table, doing nothing if it's already there
+func (cm *CaseManager) addColumn(table, column string) error {
+	_, err := cm.db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", table, column))
+	if err != nil && strings.Contains(err.Error(), "duplicate column name") {
+		return nil
+	}
+	return err
+}
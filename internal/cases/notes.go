@@ -0,0 +1,72 @@
+// internal/cases/notes.go
+package cases
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Note is something staff want to remember about a user, which isn't an infraction
+type Note struct {
+	ID        int64
+	GuildID   string
+	UserID    string
+	AuthorID  string
+	Text      string
+	CreatedAt time.Time
+}
+
+// AddNote stores a note, setting its ID
+func (cm *CaseManager) AddNote(n *Note) error {
+	result, err := cm.db.Exec(`
+		INSERT INTO user_notes (guild_id, user_id, author_id, text, created_at)
+		VALUES (?, ?, ?, ?, ?)`,
+		n.GuildID, n.UserID, n.AuthorID, n.Text, n.CreatedAt.Unix())
+	if err != nil {
+		return err
+	}
+	n.ID, err = result.LastInsertId()
+	return err
+}
+
+// GetNotes returns the notes on a user in a guild, newest first
+func (cm *CaseManager) GetNotes(guildID, userID string) ([]Note, error) {
+	rows, err := cm.db.Query("SELECT id, guild_id, user_id, author_id, text, created_at FROM user_notes WHERE guild_id = ? AND user_id = ? ORDER BY id DESC", guildID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notes []Note
+	for rows.Next() {
+		var n Note
+		var createdAt int64
+		if err := rows.Scan(&n.ID, &n.GuildID, &n.UserID, &n.AuthorID, &n.Text, &createdAt); err != nil {
+			return nil, err
+		}
+		n.CreatedAt = time.Unix(createdAt, 0)
+		notes = append(notes, n)
+	}
+	return notes, rows.Err()
+}
+
+// RemoveNote deletes a note, returning the removed note or sql.ErrNoRows if the guild has no such note
+func (cm *CaseManager) RemoveNote(guildID string, id int64) (*Note, error) {
+	n := &Note{}
+	var createdAt int64
+	err := cm.db.QueryRow("SELECT id, guild_id, user_id, author_id, text, created_at FROM user_notes WHERE guild_id = ? AND id = ?", guildID, id).
+		Scan(&n.ID, &n.GuildID, &n.UserID, &n.AuthorID, &n.Text, &createdAt)
+	if err != nil {
+		return nil, err
+	}
+	n.CreatedAt = time.Unix(createdAt, 0)
+
+	result, err := cm.db.Exec("DELETE FROM user_notes WHERE guild_id = ? AND id = ?", guildID, id)
+	if err != nil {
+		return nil, err
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return n, nil
+}
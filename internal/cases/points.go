@@ -0,0 +1,122 @@
+// internal/cases/points.go
+package cases
+
+import (
+	"database/sql"
+	"time"
+)
+
+// DefaultPoints is the point weight of each built-in action until a guild changes it
+var DefaultPoints = map[string]int{
+	"verbal_warn": 0,
+	"bot_warn":    1,
+	"timeout":     2,
+	"isolate":     3,
+	"kick":        4,
+	"ban":         5,
+	"tempban":     4,
+	"unban":       0,
+	"other":       0,
+	"restore":     0,
+}
+
+// Threshold is an automatic escalation, applied when a member's active points reach Points
+type Threshold struct {
+	Points   int
+	Action   string
+	Duration time.Duration // Only used for timeouts
+}
+
+// GetActionPoints returns the point weight of an action in a guild
+func (cm *CaseManager) GetActionPoints(guildID, action string) (int, error) {
+	var points int
+	err := cm.db.QueryRow("SELECT points FROM action_points WHERE guild_id = ? AND action = ?", guildID, action).Scan(&points)
+	if err == sql.ErrNoRows {
+		return DefaultPoints[action], nil
+	}
+	return points, err
+}
+
+// GetAllActionPoints returns the point weight of every action with a weight in the guild, including defaults
+func (cm *CaseManager) GetAllActionPoints(guildID string) (map[string]int, error) {
+	weights := make(map[string]int)
+	for action, points := range DefaultPoints {
+		weights[action] = points
+	}
+
+	rows, err := cm.db.Query("SELECT action, points FROM action_points WHERE guild_id = ?", guildID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var action string
+		var points int
+		if err := rows.Scan(&action, &points); err != nil {
+			return nil, err
+		}
+		weights[action] = points
+	}
+	return weights, rows.Err()
+}
+
+func (cm *CaseManager) SetActionPoints(guildID, action string, points int) error {
+	_, err := cm.db.Exec(`
+		INSERT INTO action_points (guild_id, action, points)
+		VALUES (?, ?, ?)
+		ON CONFLICT(guild_id, action) DO UPDATE SET points = ?`,
+		guildID, action, points, points)
+	return err
+}
+
+// ActivePoints sums the points of a user's cases created after since
+func (cm *CaseManager) ActivePoints(guildID, userID string, since time.Time) (int, error) {
+	var points int
+	err := cm.db.QueryRow("SELECT COALESCE(SUM(points), 0) FROM mod_cases WHERE guild_id = ? AND target_id = ? AND created_at > ?",
+		guildID, userID, since.Unix()).Scan(&points)
+	return points, err
+}
+
+// GetThresholds returns the guild's escalation thresholds, lowest first
+func (cm *CaseManager) GetThresholds(guildID string) ([]Threshold, error) {
+	rows, err := cm.db.Query("SELECT points, action, duration_seconds FROM escalation_thresholds WHERE guild_id = ? ORDER BY points", guildID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var thresholds []Threshold
+	for rows.Next() {
+		var t Threshold
+		var seconds int64
+		if err := rows.Scan(&t.Points, &t.Action, &seconds); err != nil {
+			return nil, err
+		}
+		t.Duration = time.Duration(seconds) * time.Second
+		thresholds = append(thresholds, t)
+	}
+	return thresholds, rows.Err()
+}
+
+// SetThreshold adds a threshold, replacing any existing one at the same points
+func (cm *CaseManager) SetThreshold(guildID string, t Threshold) error {
+	seconds := int64(t.Duration / time.Second)
+	_, err := cm.db.Exec(`
+		INSERT INTO escalation_thresholds (guild_id, points, action, duration_seconds)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(guild_id, points) DO UPDATE SET action = ?, duration_seconds = ?`,
+		guildID, t.Points, t.Action, seconds, t.Action, seconds)
+	return err
+}
+
+// RemoveThreshold deletes a threshold, returning sql.ErrNoRows if there wasn't one
+func (cm *CaseManager) RemoveThreshold(guildID string, points int) error {
+	result, err := cm.db.Exec("DELETE FROM escalation_thresholds WHERE guild_id = ? AND points = ?", guildID, points)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return sql.ErrNoRows
+	}
+	return err
+}
@@ -0,0 +1,143 @@
+// internal/cases/rules.go
+package cases
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// Rule is one of a guild's numbered server rules
+type Rule struct {
+	GuildID string
+	Number  int
+	Text    string
+}
+
+// Reason is how the rule is cited in a case reason, e.g. "Rule 3 - No spam"
+func (r Rule) Reason() string {
+	return fmt.Sprintf("Rule %v - %v", r.Number, r.Text)
+}
+
+// Preset is a saved reason, optionally citing a rule
+type Preset struct {
+	ID      int64
+	GuildID string
+	Rule    int // 0 if none
+	Text    string
+}
+
+// Reason is the preset as it's written in a case reason
+func (p Preset) Reason() string {
+	if p.Rule == 0 {
+		return p.Text
+	}
+	return fmt.Sprintf("Rule %v - %v", p.Rule, p.Text)
+}
+
+// ruleReference matches a reason that starts by citing a rule
+var ruleReference = regexp.MustCompile(`(?i)^\s*rule\s*#?(\d+)\b`)
+
+// RuleFromReason returns the rule a reason cites, or 0 if it doesn't start with one
+func RuleFromReason(reason string) int {
+	m := ruleReference.FindStringSubmatch(reason)
+	if m == nil {
+		return 0
+	}
+	number, err := strconv.Atoi(m[1])
+	if err != nil {
+		return 0
+	}
+	return number
+}
+
+// AddRule stores a rule, replacing any with the same number.
+// A number of 0 adds it after the guild's last rule, and the rule's number is set either way.
+func (cm *CaseManager) AddRule(r *Rule) error {
+	if r.Number == 0 {
+		err := cm.db.QueryRow("SELECT COALESCE(MAX(number), 0) + 1 FROM server_rules WHERE guild_id = ?", r.GuildID).Scan(&r.Number)
+		if err != nil {
+			return err
+		}
+	}
+	_, err := cm.db.Exec(`
+		INSERT INTO server_rules (guild_id, number, text)
+		VALUES (?, ?, ?)
+		ON CONFLICT(guild_id, number) DO UPDATE SET text = excluded.text`,
+		r.GuildID, r.Number, r.Text)
+	return err
+}
+
+// GetRules returns a guild's rules in order
+func (cm *CaseManager) GetRules(guildID string) ([]Rule, error) {
+	rows, err := cm.db.Query("SELECT guild_id, number, text FROM server_rules WHERE guild_id = ? ORDER BY number", guildID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []Rule
+	for rows.Next() {
+		var r Rule
+		if err := rows.Scan(&r.GuildID, &r.Number, &r.Text); err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+	return rules, rows.Err()
+}
+
+// RemoveRule deletes a rule, returning sql.ErrNoRows if the guild has no such rule.
+// Presets citing it are kept, as are the cases logged under it.
+func (cm *CaseManager) RemoveRule(guildID string, number int) error {
+	result, err := cm.db.Exec("DELETE FROM server_rules WHERE guild_id = ? AND number = ?", guildID, number)
+	if err != nil {
+		return err
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// AddPreset stores a reason preset, setting its ID
+func (cm *CaseManager) AddPreset(p *Preset) error {
+	result, err := cm.db.Exec("INSERT INTO reason_presets (guild_id, rule, text) VALUES (?, ?, ?)", p.GuildID, p.Rule, p.Text)
+	if err != nil {
+		return err
+	}
+	p.ID, err = result.LastInsertId()
+	return err
+}
+
+// GetPresets returns a guild's reason presets, by rule and then oldest first
+func (cm *CaseManager) GetPresets(guildID string) ([]Preset, error) {
+	rows, err := cm.db.Query("SELECT id, guild_id, rule, text FROM reason_presets WHERE guild_id = ? ORDER BY rule, id", guildID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var presets []Preset
+	for rows.Next() {
+		var p Preset
+		if err := rows.Scan(&p.ID, &p.GuildID, &p.Rule, &p.Text); err != nil {
+			return nil, err
+		}
+		presets = append(presets, p)
+	}
+	return presets, rows.Err()
+}
+
+// RemovePreset deletes a reason preset, returning sql.ErrNoRows if the guild has no such preset
+func (cm *CaseManager) RemovePreset(guildID string, id int64) error {
+	result, err := cm.db.Exec("DELETE FROM reason_presets WHERE guild_id = ? AND id = ?", guildID, id)
+	if err != nil {
+		return err
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+// internal/cases/tempbans.go
+package cases
+
+import "time"
+
+// TempBan is a ban that should be lifted once it expires
+type TempBan struct {
+	GuildID    string
+	UserID     string
+	ExpiresAt  time.Time
+	CaseNumber int // The case that logged the ban, 0 if it wasn't logged
+}
+
+// AddTempBan schedules a user to be unbanned, replacing any existing expiry
+func (cm *CaseManager) AddTempBan(t TempBan) error {
+	_, err := cm.db.Exec(`
+		INSERT INTO temp_bans (guild_id, user_id, expires_at, case_number)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(guild_id, user_id) DO UPDATE SET expires_at = ?, case_number = ?`,
+		t.GuildID, t.UserID, t.ExpiresAt.Unix(), t.CaseNumber, t.ExpiresAt.Unix(), t.CaseNumber)
+	return err
+}
+
+func (cm *CaseManager) RemoveTempBan(guildID, userID string) error {
+	_, err := cm.db.Exec("DELETE FROM temp_bans WHERE guild_id = ? AND user_id = ?", guildID, userID)
+	return err
+}
+
+// ExpiredTempBans returns every temporary ban that expired before now
+func (cm *CaseManager) ExpiredTempBans(now time.Time) ([]TempBan, error) {
+	rows, err := cm.db.Query("SELECT guild_id, user_id, expires_at, case_number FROM temp_bans WHERE expires_at <= ?", now.Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bans []TempBan
+	for rows.Next() {
+		var t TempBan
+		var expiresAt int64
+		if err := rows.Scan(&t.GuildID, &t.UserID, &expiresAt, &t.CaseNumber); err != nil {
+			return nil, err
+		}
+		t.ExpiresAt = time.Unix(expiresAt, 0)
+		bans = append(bans, t)
+	}
+	return bans, rows.Err()
+}
@@ -0,0 +1,48 @@
+// internal/cases/tempisolations.go
+package cases
+
+import "time"
+
+// TempIsolation is an isolation that should be lifted once it expires
+type TempIsolation struct {
+	GuildID    string
+	UserID     string
+	ExpiresAt  time.Time
+	CaseNumber int // The case that logged the isolation, 0 if it wasn't logged
+}
+
+// AddTempIsolation schedules a user to be restored, replacing any existing expiry
+func (cm *CaseManager) AddTempIsolation(t TempIsolation) error {
+	_, err := cm.db.Exec(`
+		INSERT INTO temp_isolations (guild_id, user_id, expires_at, case_number)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(guild_id, user_id) DO UPDATE SET expires_at = ?, case_number = ?`,
+		t.GuildID, t.UserID, t.ExpiresAt.Unix(), t.CaseNumber, t.ExpiresAt.Unix(), t.CaseNumber)
+	return err
+}
+
+func (cm *CaseManager) RemoveTempIsolation(guildID, userID string) error {
+	_, err := cm.db.Exec("DELETE FROM temp_isolations WHERE guild_id = ? AND user_id = ?", guildID, userID)
+	return err
+}
+
+// ExpiredTempIsolations returns every temporary isolation that expired before now
+func (cm *CaseManager) ExpiredTempIsolations(now time.Time) ([]TempIsolation, error) {
+	rows, err := cm.db.Query("SELECT guild_id, user_id, expires_at, case_number FROM temp_isolations WHERE expires_at <= ?", now.Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var isolations []TempIsolation
+	for rows.Next() {
+		var t TempIsolation
+		var expiresAt int64
+		if err := rows.Scan(&t.GuildID, &t.UserID, &expiresAt, &t.CaseNumber); err != nil {
+			return nil, err
+		}
+		t.ExpiresAt = time.Unix(expiresAt, 0)
+		isolations = append(isolations, t)
+	}
+	return isolations, rows.Err()
+}
@@ -0,0 +1,25 @@
+// internal/cases/userposts.go
+package cases
+
+// GetUserPost returns the forum post holding a user's mod log, or sql.ErrNoRows if they don't have one in that forum yet
+func (cm *CaseManager) GetUserPost(guildID, forumID, userID string) (string, error) {
+	var threadID string
+	err := cm.db.QueryRow("SELECT thread_id FROM user_log_posts WHERE guild_id = ? AND forum_id = ? AND user_id = ?", guildID, forumID, userID).Scan(&threadID)
+	return threadID, err
+}
+
+// SetUserPost records the forum post holding a user's mod log
+func (cm *CaseManager) SetUserPost(guildID, forumID, userID, threadID string) error {
+	_, err := cm.db.Exec(`
+		INSERT INTO user_log_posts (guild_id, forum_id, user_id, thread_id)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(guild_id, forum_id, user_id) DO UPDATE SET thread_id = excluded.thread_id`,
+		guildID, forumID, userID, threadID)
+	return err
+}
+
+// DeleteUserPost forgets a user's forum post, so a new one is made
+func (cm *CaseManager) DeleteUserPost(guildID, forumID, userID string) error {
+	_, err := cm.db.Exec("DELETE FROM user_log_posts WHERE guild_id = ? AND forum_id = ? AND user_id = ?", guildID, forumID, userID)
+	return err
+}
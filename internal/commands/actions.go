@@ -0,0 +1,151 @@
+// internal/commands/actions.go
+package commands
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/shininglegend/shieldbot/internal/cases"
+	"github.com/shininglegend/shieldbot/pkg/utils"
+)
+
+const (
+	// The /config actions subcommand group
+	ActionsGroupName  = "actions"
+	ActionsAddName    = "add"
+	ActionsRemoveName = "remove"
+	ActionsListName   = "list"
+
+	// Used when an action is added without a color
+	defaultActionColor = 0x0000FF // Blue
+)
+
+func (pc *PermissionCommands) handleActions(s *discordgo.Session, i *discordgo.InteractionCreate, options []*discordgo.ApplicationCommandInteractionDataOption) *discordgo.MessageEmbed {
+	subcommand := options[0]
+	switch subcommand.Name {
+	case ActionsAddName:
+		return pc.handleAddAction(s, i, subcommand.Options)
+	case ActionsRemoveName:
+		return pc.handleRemoveAction(s, i, subcommand.Options)
+	case ActionsListName:
+		return pc.handleListActions(s, i)
+	default:
+		return utils.CreateNotAllowedEmbed("Unknown subcommand to config actions", fmt.Sprintf("Unknown subcommand: %v", subcommand.Name))
+	}
+}
+
+func (pc *PermissionCommands) handleAddAction(s *discordgo.Session, i *discordgo.InteractionCreate, options []*discordgo.ApplicationCommandInteractionDataOption) *discordgo.MessageEmbed {
+	label := strings.TrimSpace(options[0].StringValue())
+	a := cases.ActionType{
+		Name:  actionName(label),
+		Label: label,
+		Color: defaultActionColor,
+	}
+	if a.Name == "" {
+		return utils.CreateNotAllowedEmbed("Invalid label", "The label needs at least one letter or number.")
+	}
+	if _, ok := cases.DefaultPoints[a.Name]; ok {
+		return utils.CreateNotAllowedEmbed("Built-in action", fmt.Sprintf("`%s` is a built-in action. Use /config setpoints to change its points.", a.Name))
+	}
+
+	if opt := utils.FindOption(options, "color"); opt != nil {
+		color, err := strconv.ParseInt(strings.TrimPrefix(strings.TrimSpace(opt.StringValue()), "#"), 16, 32)
+		if err != nil || color < 0 || color > 0xFFFFFF {
+			return utils.CreateNotAllowedEmbed("Invalid color", "Use a hex color like `#FF8800`.")
+		}
+		a.Color = int(color)
+	}
+	points := 0
+	if opt := utils.FindOption(options, "points"); opt != nil {
+		points = int(opt.IntValue())
+	}
+
+	oldAction := ""
+	if old, err := pc.cm.GetActionType(i.GuildID, a.Name); err == nil {
+		oldAction = pc.actionTypeValue(i.GuildID, *old)
+	}
+	err := pc.cm.AddActionType(i.GuildID, a, points)
+	if err != nil {
+		return utils.CreateErrorEmbed(s, i, "Error adding action", err)
+	}
+	pc.audit(s, i, fmt.Sprintf("Custom action %s", a.Name), oldAction, formatActionType(a, points))
+	embed := utils.CreateEmbed("Action Added", fmt.Sprintf("**%s** (`%s`) can now be logged, adding %d point(s).", a.Label, a.Name, points))
+	embed.Color = a.Color
+	return embed
+}
+
+func (pc *PermissionCommands) handleRemoveAction(s *discordgo.Session, i *discordgo.InteractionCreate, options []*discordgo.ApplicationCommandInteractionDataOption) *discordgo.MessageEmbed {
+	name := options[0].StringValue()
+	oldAction := ""
+	if old, err := pc.cm.GetActionType(i.GuildID, name); err == nil {
+		oldAction = pc.actionTypeValue(i.GuildID, *old)
+	}
+	err := pc.cm.RemoveActionType(i.GuildID, name)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return utils.CreateNotAllowedEmbed("No action found", fmt.Sprintf("There is no custom action `%s`. Built-in actions can't be removed.", name))
+		}
+		return utils.CreateErrorEmbed(s, i, "Error removing action", err)
+	}
+	pc.audit(s, i, fmt.Sprintf("Custom action %s", name), oldAction, "")
+	return utils.CreateEmbed("Action Removed", fmt.Sprintf("`%s` can no longer be logged. Cases already logged with it are kept.", name))
+}
+
+func (pc *PermissionCommands) handleListActions(s *discordgo.Session, i *discordgo.InteractionCreate) *discordgo.MessageEmbed {
+	actions, err := pc.cm.GetActionTypes(i.GuildID)
+	if err != nil {
+		return utils.CreateErrorEmbed(s, i, "Error retrieving actions", err)
+	}
+
+	var description strings.Builder
+	if len(actions) == 0 {
+		description.WriteString("No custom actions. Add one with /config actions add.")
+	}
+	for _, a := range actions {
+		points, err := pc.cm.GetActionPoints(i.GuildID, a.Name)
+		if err != nil {
+			return utils.CreateErrorEmbed(s, i, "Error retrieving points", err)
+		}
+		description.WriteString(fmt.Sprintf("**%s** (`%s`): #%06X, %d point(s)\n", a.Label, a.Name, a.Color, points))
+	}
+	return utils.CreateEmbed("Custom Actions", description.String())
+}
+
+// actionTypeValue describes a saved custom action for the audit log
+func (pc *PermissionCommands) actionTypeValue(guildID string, a cases.ActionType) string {
+	points, err := pc.cm.GetActionPoints(guildID, a.Name)
+	if err != nil {
+		return formatActionType(a, 0)
+	}
+	return formatActionType(a, points)
+}
+
+// formatActionType describes a custom action for the audit log
+func formatActionType(a cases.ActionType, points int) string {
+	return fmt.Sprintf("%s, #%06X, %d point(s)", a.Label, a.Color, points)
+}
+
+// actionName turns an action label into the name stored with cases, like "Spam Warning" into "spam_warning"
+func actionName(label string) string {
+	var name strings.Builder
+	for _, r := range strings.ToLower(label) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			name.WriteRune(r)
+		case r == ' ' || r == '_' || r == '-':
+			if name.Len() > 0 && !strings.HasSuffix(name.String(), "_") {
+				name.WriteRune('_')
+			}
+		}
+	}
+
+	// Keep it short enough for custom IDs
+	result := strings.TrimSuffix(name.String(), "_")
+	if len(result) > 32 {
+		result = strings.TrimSuffix(result[:32], "_")
+	}
+	return result
+}
@@ -0,0 +1,138 @@
+// internal/commands/audit.go
+package commands
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/shininglegend/shieldbot/internal/logging"
+	"github.com/shininglegend/shieldbot/internal/permissions"
+	"github.com/shininglegend/shieldbot/pkg/utils"
+)
+
+const (
+	AuditLogName = "auditlog"
+
+	// Number of changes shown per page of /config auditlog
+	auditLogPageSize = 10
+)
+
+// audit records a configuration change and posts it to the config log.
+// Failing to do either doesn't undo the change, so errors are only logged.
+func (pc *PermissionCommands) audit(s *discordgo.Session, i *discordgo.InteractionCreate, setting, oldValue, newValue string) {
+	if oldValue == newValue {
+		return
+	}
+	e := &permissions.AuditEntry{
+		GuildID:   i.GuildID,
+		UserID:    utils.SafeUser(i.Interaction).ID,
+		Setting:   setting,
+		OldValue:  oldValue,
+		NewValue:  newValue,
+		CreatedAt: time.Now(),
+	}
+	err := pc.pm.AddAuditEntry(e)
+	if err != nil {
+		log.Printf("Error recording config change: %v", err)
+	}
+
+	err = pc.logger.SendEmbed(s, i.GuildID, logging.CategoryConfig, &discordgo.MessageEmbed{
+		Title:       "Config Changed",
+		Description: fmt.Sprintf("<@%v> changed **%v**", e.UserID, setting),
+		Color:       0x0000FF, // Blue
+		Timestamp:   e.CreatedAt.Format(time.RFC3339),
+		Fields: []*discordgo.MessageEmbedField{
+			{
+				Name:  "Old",
+				Value: auditValue(oldValue, 1024),
+			},
+			{
+				Name:  "New",
+				Value: auditValue(newValue, 1024),
+			},
+		},
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("Change #%v", e.ID),
+		},
+	})
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("Error posting config change: %v", err)
+	}
+}
+
+func (pc *PermissionCommands) handleAuditLog(s *discordgo.Session, i *discordgo.InteractionCreate, options []*discordgo.ApplicationCommandInteractionDataOption) *discordgo.MessageEmbed {
+	page := 1
+	if opt := utils.FindOption(options, "page"); opt != nil {
+		page = int(opt.IntValue())
+	}
+
+	total, err := pc.pm.CountAuditLog(i.GuildID)
+	if err != nil {
+		return utils.CreateErrorEmbed(s, i, "Error retrieving config changes", err)
+	}
+	pages := max(1, (total+auditLogPageSize-1)/auditLogPageSize)
+	if page < 1 || page > pages {
+		return utils.CreateNotAllowedEmbed("Page not found", fmt.Sprintf("There are %d page(s) of changes.", pages))
+	}
+	entries, err := pc.pm.GetAuditLog(i.GuildID, auditLogPageSize, (page-1)*auditLogPageSize)
+	if err != nil {
+		return utils.CreateErrorEmbed(s, i, "Error retrieving config changes", err)
+	}
+
+	var description strings.Builder
+	if len(entries) == 0 {
+		description.WriteString("No config changes recorded yet.")
+	}
+	for _, e := range entries {
+		description.WriteString(fmt.Sprintf("`#%d` <t:%d:R> <@%s> **%s**: %s → %s\n",
+			e.ID, e.CreatedAt.Unix(), e.UserID, e.Setting, auditValue(e.OldValue, 150), auditValue(e.NewValue, 150)))
+	}
+	embed := utils.CreateEmbed("Config Audit Log", description.String())
+	embed.Footer = &discordgo.MessageEmbedFooter{
+		Text: fmt.Sprintf("Page %d of %d, %d change(s)", page, pages, total),
+	}
+	return embed
+}
+
+// auditValue formats a setting value for display
+func auditValue(value string, maxLen int) string {
+	if value == "" {
+		return "*Not set*"
+	}
+	if runes := []rune(value); len(runes) > maxLen {
+		return string(runes[:maxLen-3]) + "..."
+	}
+	return value
+}
+
+// mentionChannel formats a channel ID for the audit log, keeping empty IDs empty
+func mentionChannel(channelID string) string {
+	if channelID == "" {
+		return ""
+	}
+	return fmt.Sprintf("<#%s>", channelID)
+}
+
+// mentionRoles formats role IDs for the audit log
+func mentionRoles(roleIDs []string) string {
+	mentions := make([]string, len(roleIDs))
+	for idx, roleID := range roleIDs {
+		mentions[idx] = fmt.Sprintf("<@&%s>", roleID)
+	}
+	return strings.Join(mentions, " ")
+}
+
+// settingValue returns a setting for the audit log, empty if it isn't set
+func settingValue(value string, err error) string {
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("Error fetching previous setting: %v", err)
+		}
+		return ""
+	}
+	return value
+}
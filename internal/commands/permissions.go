@@ -3,9 +3,13 @@ package commands
 
 import (
 	"fmt"
+	"log"
 	"strings"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
+	"github.com/shininglegend/shieldbot/internal/cases"
+	"github.com/shininglegend/shieldbot/internal/logging"
 	"github.com/shininglegend/shieldbot/internal/permissions"
 	"github.com/shininglegend/shieldbot/pkg/auth"
 	"github.com/shininglegend/shieldbot/pkg/utils"
@@ -17,14 +21,26 @@ const (
 	RemovePermName       = "removeperm"
 	SetIsolationRoleName = "setisolationrole"
 	SetLogChannel        = "setlogchannel"
+	SetAppealMessageName = "setappealmessage"
+	SetAppealsChannel    = "setappealschannel"
+	SetModmailChannel    = "setmodmailchannel"
+	CaseThreadsName      = "casethreads"
+
+	// Used when case threads are enabled without an archive duration, in minutes
+	defaultCaseThreadArchive = 1440
+
+	// Placeholders that can be used in the appeal message
+	AppealPlaceholders = "`{user}`, `{guild}`, `{moderator}`, `{reason}`, `{duration}`"
 )
 
 type PermissionCommands struct {
-	pm *permissions.PermissionManager
+	pm     *permissions.PermissionManager
+	cm     *cases.CaseManager
+	logger *logging.Logger
 }
 
-func NewPermissionCommands(pm *permissions.PermissionManager) *PermissionCommands {
-	return &PermissionCommands{pm: pm}
+func NewPermissionCommands(pm *permissions.PermissionManager, cm *cases.CaseManager, logger *logging.Logger) *PermissionCommands {
+	return &PermissionCommands{pm: pm, cm: cm, logger: logger}
 }
 
 func (pc *PermissionCommands) HandleConfig(s *discordgo.Session, i *discordgo.InteractionCreate) *discordgo.MessageEmbed {
@@ -46,6 +62,28 @@ func (pc *PermissionCommands) HandleConfig(s *discordgo.Session, i *discordgo.In
 		return pc.handleSetIsolationRole(s, i, options[0].Options)
 	case SetLogChannel:
 		return pc.handleSetLogChannel(s, i, options[0].Options)
+	case SetAppealsChannel:
+		return pc.handleSetAppealsChannel(s, i, options[0].Options)
+	case SetModmailChannel:
+		return pc.handleSetModmailChannel(s, i, options[0].Options)
+	case CaseThreadsName:
+		return pc.handleCaseThreads(s, i, options[0].Options)
+	case SetAppealMessageName:
+		return pc.handleSetAppealMessage(s, i, options[0].Options)
+	case SetPointsName:
+		return pc.handleSetPoints(s, i, options[0].Options)
+	case AddThresholdName:
+		return pc.handleAddThreshold(s, i, options[0].Options)
+	case RemoveThresholdName:
+		return pc.handleRemoveThreshold(s, i, options[0].Options)
+	case ViewPointsName:
+		return pc.handleViewPoints(s, i)
+	case SetPointDecayName:
+		return pc.handleSetPointDecay(s, i, options[0].Options)
+	case ActionsGroupName:
+		return pc.handleActions(s, i, options[0].Options)
+	case AuditLogName:
+		return pc.handleAuditLog(s, i, options[0].Options)
 	default:
 		return utils.CreateNotAllowedEmbed("Unknown subcommand to config", fmt.Sprintf("Unknown subcommand: %v", subcommand))
 	}
@@ -80,11 +118,16 @@ func (pc *PermissionCommands) handleViewPerms(s *discordgo.Session, in *discordg
 func (pc *PermissionCommands) handleAddPerm(s *discordgo.Session, i *discordgo.InteractionCreate, options []*discordgo.ApplicationCommandInteractionDataOption) *discordgo.MessageEmbed {
 	commandName := options[0].StringValue()
 	role := options[1].RoleValue(s, i.GuildID)
+	before, err := pc.commandRoles(i.GuildID, commandName)
+	if err != nil {
+		return utils.CreateErrorEmbed(s, i, "Error retrieving permissions", err)
+	}
 
-	err := pc.pm.SetCommandPermission(i.GuildID, commandName, role.ID)
+	err = pc.pm.SetCommandPermission(i.GuildID, commandName, role.ID)
 	if err != nil {
 		return utils.CreateErrorEmbed(s, i, "Error adding permission", err)
 	}
+	pc.auditCommandRoles(s, i, commandName, before)
 	return utils.CreateEmbed("Permission Added", fmt.Sprintf("Permission for command '%s' has been granted to role %s", commandName, role.Mention()))
 }
 
@@ -112,10 +155,12 @@ func (pc *PermissionCommands) handleRemovePerm(s *discordgo.Session, i *discordg
 		return utils.CreateNotAllowedEmbed("Role not found", "Role not found in the server.")
 	}
 
+	before := append([]string{}, perms[commandName]...)
 	err = pc.pm.RemoveCommandPermission(i.GuildID, commandName, role.ID)
 	if err != nil {
 		return utils.CreateErrorEmbed(s, i, "Error removing permission", err)
 	}
+	pc.auditCommandRoles(s, i, commandName, before)
 
 	return utils.CreateEmbed("Permission Removed", fmt.Sprintf("Permission for command '%s' has been removed from role %s", commandName, role.Mention()))
 }
@@ -157,22 +202,31 @@ func (pc *PermissionCommands) handleSetIsolationRole(s *discordgo.Session, i *di
 		return utils.CreateNotAllowedEmbed("Insufficient bot role hierarchy", "The bot's highest role is not above the specified isolation role")
 	}
 
+	oldRole := settingValue(pc.pm.GetIsolationRoleID(i.GuildID))
 	err = pc.pm.SetIsolationRole(i.GuildID, role.ID)
 	if err != nil {
 		return utils.CreateErrorEmbed(s, i, "Error setting isolation role", err)
 	}
+	if oldRole != "" {
+		oldRole = fmt.Sprintf("<@&%s>", oldRole)
+	}
+	pc.audit(s, i, "Isolation role", oldRole, role.Mention())
 	return utils.CreateEmbed("Isolation Role Set", fmt.Sprintf("Isolation role has been set to %s", role.Mention()))
 }
 
 func (pc *PermissionCommands) handleSetLogChannel(s *discordgo.Session, i *discordgo.InteractionCreate, options []*discordgo.ApplicationCommandInteractionDataOption) *discordgo.MessageEmbed {
-	channel := options[0].ChannelValue(s)
+	channel := utils.FindOption(options, "channel").ChannelValue(s)
 	// Ensure the channel exists and you have perms to manage it
 	if channel == nil {
 		return utils.CreateNotAllowedEmbed("Error setting log channel", "The specified channel does not exist")
 	}
+	category := logging.CategoryMain
+	if opt := utils.FindOption(options, "category"); opt != nil {
+		category = logging.Category(opt.StringValue())
+	}
 
-	// Get the bot's permissions in the guild
-	botPerms, err := s.State.UserChannelPermissions(s.State.User.ID, i.ChannelID)
+	// Get the bot's permissions in the channel
+	botPerms, err := s.State.UserChannelPermissions(s.State.User.ID, channel.ID)
 	if err != nil {
 		return utils.CreateErrorEmbed(s, i, "Error checking bot permissions", err)
 	}
@@ -182,9 +236,166 @@ func (pc *PermissionCommands) handleSetLogChannel(s *discordgo.Session, i *disco
 		return utils.CreateNotAllowedEmbed("Insufficient bot permissions", "The bot doesn't have permission to send messages in the channel")
 	}
 
-	err = pc.pm.SetLogChannel(i.GuildID, channel.ID)
+	// In a forum, each user gets their own post, which only makes sense for moderation logs
+	if channel.Type == discordgo.ChannelTypeGuildForum {
+		if category != logging.CategoryModeration {
+			return utils.CreateNotAllowedEmbed("Forum not supported", "Forum channels can only be used for the moderation category, where each user gets their own post.")
+		}
+		if botPerms&discordgo.PermissionSendMessagesInThreads == 0 {
+			return utils.CreateNotAllowedEmbed("Insufficient bot permissions", "The bot needs permission to send messages in posts of the forum")
+		}
+	}
+
+	if category == logging.CategoryMain {
+		oldChannel := settingValue(pc.pm.GetLogChannelID(i.GuildID))
+		err = pc.pm.SetLogChannel(i.GuildID, channel.ID)
+		if err != nil {
+			return utils.CreateErrorEmbed(s, i, "Error setting log channel", err)
+		}
+		pc.audit(s, i, "Log channel", mentionChannel(oldChannel), channel.Mention())
+		return utils.CreateEmbed("Log Channel Set", fmt.Sprintf("Log channel has been set to %s", channel.Mention()))
+	}
+
+	label, ok := logging.CategoryLabel(category)
+	if !ok {
+		return utils.CreateNotAllowedEmbed("Error setting log channel", fmt.Sprintf("Unknown log category: %v", category))
+	}
+	oldChannel := settingValue(pc.pm.GetCategoryLogChannelID(i.GuildID, string(category)))
+	err = pc.pm.SetCategoryLogChannel(i.GuildID, string(category), channel.ID)
 	if err != nil {
 		return utils.CreateErrorEmbed(s, i, "Error setting log channel", err)
 	}
-	return utils.CreateEmbed("Log Channel Set", fmt.Sprintf("Log channel has been set to %s", channel.Mention()))
+	pc.audit(s, i, fmt.Sprintf("Log channel (%s)", category), mentionChannel(oldChannel), channel.Mention())
+	return utils.CreateEmbed("Log Channel Set", fmt.Sprintf("%s will now be logged in %s", label, channel.Mention()))
+}
+
+func (pc *PermissionCommands) handleSetAppealMessage(s *discordgo.Session, i *discordgo.InteractionCreate, options []*discordgo.ApplicationCommandInteractionDataOption) *discordgo.MessageEmbed {
+	message := strings.TrimSpace(options[0].StringValue())
+	if message == "" {
+		return utils.CreateNotAllowedEmbed("Error setting appeal message", "The appeal message can't be empty")
+	}
+	// Keep it short enough to fit in an embed field
+	if len(message) > 1000 {
+		return utils.CreateNotAllowedEmbed("Error setting appeal message", "The appeal message must be 1000 characters or less")
+	}
+
+	oldMessage := settingValue(pc.pm.GetAppealMessage(i.GuildID))
+	err := pc.pm.SetAppealMessage(i.GuildID, message)
+	if err != nil {
+		return utils.CreateErrorEmbed(s, i, "Error setting appeal message", err)
+	}
+	pc.audit(s, i, "Appeal message", oldMessage, message)
+	return utils.CreateEmbed("Appeal Message Set", fmt.Sprintf("Isolated users will now be sent the following. Placeholders: %s\n>>> %s", AppealPlaceholders, message))
+}
+
+func (pc *PermissionCommands) handleSetAppealsChannel(s *discordgo.Session, i *discordgo.InteractionCreate, options []*discordgo.ApplicationCommandInteractionDataOption) *discordgo.MessageEmbed {
+	channel := options[0].ChannelValue(s)
+	if channel == nil {
+		return utils.CreateNotAllowedEmbed("Error setting appeals channel", "The specified channel does not exist")
+	}
+
+	// Appeals are posted with buttons, so the bot needs to be able to send messages there
+	botPerms, err := s.State.UserChannelPermissions(s.State.User.ID, channel.ID)
+	if err != nil {
+		return utils.CreateErrorEmbed(s, i, "Error checking bot permissions", err)
+	}
+	if botPerms&discordgo.PermissionSendMessages == 0 {
+		return utils.CreateNotAllowedEmbed("Insufficient bot permissions", "The bot doesn't have permission to send messages in the channel")
+	}
+
+	oldChannel := settingValue(pc.pm.GetAppealsChannelID(i.GuildID))
+	err = pc.pm.SetAppealsChannel(i.GuildID, channel.ID)
+	if err != nil {
+		return utils.CreateErrorEmbed(s, i, "Error setting appeals channel", err)
+	}
+	pc.audit(s, i, "Appeals channel", mentionChannel(oldChannel), channel.Mention())
+	return utils.CreateEmbed("Appeals Channel Set", fmt.Sprintf("Isolation appeals will be posted in %s", channel.Mention()))
+}
+
+func (pc *PermissionCommands) handleSetModmailChannel(s *discordgo.Session, i *discordgo.InteractionCreate, options []*discordgo.ApplicationCommandInteractionDataOption) *discordgo.MessageEmbed {
+	channel := options[0].ChannelValue(s)
+	if channel == nil {
+		return utils.CreateNotAllowedEmbed("Error setting modmail channel", "The specified channel does not exist")
+	}
+
+	// Each conversation is a thread in this channel
+	botPerms, err := s.State.UserChannelPermissions(s.State.User.ID, channel.ID)
+	if err != nil {
+		return utils.CreateErrorEmbed(s, i, "Error checking bot permissions", err)
+	}
+	if botPerms&discordgo.PermissionSendMessages == 0 || botPerms&discordgo.PermissionCreatePublicThreads == 0 {
+		return utils.CreateNotAllowedEmbed("Insufficient bot permissions", "The bot needs permission to send messages and create threads in the channel")
+	}
+
+	oldChannel := settingValue(pc.pm.GetModmailChannelID(i.GuildID))
+	err = pc.pm.SetModmailChannel(i.GuildID, channel.ID)
+	if err != nil {
+		return utils.CreateErrorEmbed(s, i, "Error setting modmail channel", err)
+	}
+	pc.audit(s, i, "Modmail channel", mentionChannel(oldChannel), channel.Mention())
+	return utils.CreateEmbed("Modmail Channel Set", fmt.Sprintf("Messages from members will open threads in %s", channel.Mention()))
+}
+
+func (pc *PermissionCommands) handleCaseThreads(s *discordgo.Session, i *discordgo.InteractionCreate, options []*discordgo.ApplicationCommandInteractionDataOption) *discordgo.MessageEmbed {
+	enabled := utils.FindOption(options, "enabled").BoolValue()
+	archiveMinutes := defaultCaseThreadArchive
+	if opt := utils.FindOption(options, "archive"); opt != nil {
+		archiveMinutes = int(opt.IntValue())
+	}
+
+	// Threads are opened in the moderation log channel
+	if enabled {
+		channelID, err := pc.logger.ChannelID(i.GuildID, logging.CategoryModeration)
+		if err == nil {
+			botPerms, err := s.State.UserChannelPermissions(s.State.User.ID, channelID)
+			if err != nil {
+				return utils.CreateErrorEmbed(s, i, "Error checking bot permissions", err)
+			}
+			if botPerms&discordgo.PermissionCreatePublicThreads == 0 {
+				return utils.CreateNotAllowedEmbed("Insufficient bot permissions", "The bot needs permission to create threads in the moderation log channel")
+			}
+		}
+	}
+
+	oldEnabled, oldArchive, err := pc.pm.GetCaseThreads(i.GuildID)
+	if err != nil {
+		return utils.CreateErrorEmbed(s, i, "Error retrieving case threads", err)
+	}
+	err = pc.pm.SetCaseThreads(i.GuildID, enabled, archiveMinutes)
+	if err != nil {
+		return utils.CreateErrorEmbed(s, i, "Error setting case threads", err)
+	}
+	pc.audit(s, i, "Case threads", caseThreadsValue(oldEnabled, oldArchive), caseThreadsValue(enabled, archiveMinutes))
+
+	if !enabled {
+		return utils.CreateEmbed("Case Threads Disabled", "Mod log entries will no longer get a discussion thread.")
+	}
+	return utils.CreateEmbed("Case Threads Enabled", fmt.Sprintf("Each mod log entry will get a discussion thread, archived after %s without activity.", utils.FormatDuration(time.Duration(archiveMinutes)*time.Minute)))
+}
+
+// caseThreadsValue describes the case thread setting for the audit log
+func caseThreadsValue(enabled bool, archiveMinutes int) string {
+	if !enabled {
+		return "Disabled"
+	}
+	return fmt.Sprintf("Enabled, archived after %s", utils.FormatDuration(time.Duration(archiveMinutes)*time.Minute))
+}
+
+// commandRoles returns a copy of the roles with an override for a command
+func (pc *PermissionCommands) commandRoles(guildID, commandName string) ([]string, error) {
+	perms, err := pc.pm.GetCommandPermissions(guildID)
+	if err != nil {
+		return nil, err
+	}
+	return append([]string{}, perms[commandName]...), nil
+}
+
+// auditCommandRoles records a change to the roles with an override for a command
+func (pc *PermissionCommands) auditCommandRoles(s *discordgo.Session, i *discordgo.InteractionCreate, commandName string, before []string) {
+	after, err := pc.commandRoles(i.GuildID, commandName)
+	if err != nil {
+		log.Printf("Error retrieving permissions: %v", err)
+		return
+	}
+	pc.audit(s, i, fmt.Sprintf("Permission override for /%s", commandName), mentionRoles(before), mentionRoles(after))
 }
@@ -0,0 +1,177 @@
+// internal/commands/points.go
+package commands
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/shininglegend/shieldbot/internal/cases"
+	"github.com/shininglegend/shieldbot/pkg/utils"
+)
+
+const (
+	SetPointsName       = "setpoints"
+	AddThresholdName    = "addthreshold"
+	RemoveThresholdName = "removethreshold"
+	ViewPointsName      = "viewpoints"
+	SetPointDecayName   = "setpointdecay"
+)
+
+func (pc *PermissionCommands) handleSetPoints(s *discordgo.Session, i *discordgo.InteractionCreate, options []*discordgo.ApplicationCommandInteractionDataOption) *discordgo.MessageEmbed {
+	action := options[0].StringValue()
+	points := int(options[1].IntValue())
+
+	// Actions come from autocomplete, so they may be anything
+	if _, ok := cases.DefaultPoints[action]; !ok {
+		if _, err := pc.cm.GetActionType(i.GuildID, action); err != nil {
+			if err == sql.ErrNoRows {
+				return utils.CreateNotAllowedEmbed("Unknown action", fmt.Sprintf("There is no action `%s`. See /config actions list.", action))
+			}
+			return utils.CreateErrorEmbed(s, i, "Error fetching action", err)
+		}
+	}
+
+	oldPoints, err := pc.cm.GetActionPoints(i.GuildID, action)
+	if err != nil {
+		return utils.CreateErrorEmbed(s, i, "Error retrieving points", err)
+	}
+	err = pc.cm.SetActionPoints(i.GuildID, action, points)
+	if err != nil {
+		return utils.CreateErrorEmbed(s, i, "Error setting points", err)
+	}
+	pc.audit(s, i, fmt.Sprintf("Points for %s", action), strconv.Itoa(oldPoints), strconv.Itoa(points))
+	return utils.CreateEmbed("Points Set", fmt.Sprintf("Logging `%s` now adds %d point(s). Existing cases keep their points.", action, points))
+}
+
+func (pc *PermissionCommands) handleAddThreshold(s *discordgo.Session, i *discordgo.InteractionCreate, options []*discordgo.ApplicationCommandInteractionDataOption) *discordgo.MessageEmbed {
+	t := cases.Threshold{
+		Points: int(options[0].IntValue()),
+		Action: options[1].StringValue(),
+	}
+	if opt := utils.FindOption(options, "duration"); opt != nil {
+		d, err := utils.ParseDuration(opt.StringValue())
+		if err != nil {
+			return utils.CreateNotAllowedEmbed("Invalid duration", "Use a duration like `30m`, `12h`, `3d` or `2w`.")
+		}
+		t.Duration = d
+	}
+	if t.Action == "timeout" && t.Duration == 0 {
+		return utils.CreateNotAllowedEmbed("Duration required", "Timeouts need a duration, up to 28 days.")
+	}
+
+	oldThreshold, err := pc.thresholdValue(i.GuildID, t.Points)
+	if err != nil {
+		return utils.CreateErrorEmbed(s, i, "Error retrieving thresholds", err)
+	}
+	err = pc.cm.SetThreshold(i.GuildID, t)
+	if err != nil {
+		return utils.CreateErrorEmbed(s, i, "Error adding threshold", err)
+	}
+	pc.audit(s, i, fmt.Sprintf("Threshold at %d points", t.Points), oldThreshold, formatThreshold(t))
+	return utils.CreateEmbed("Threshold Added", fmt.Sprintf("Reaching %d point(s) will now apply: %s", t.Points, formatThreshold(t)))
+}
+
+func (pc *PermissionCommands) handleRemoveThreshold(s *discordgo.Session, i *discordgo.InteractionCreate, options []*discordgo.ApplicationCommandInteractionDataOption) *discordgo.MessageEmbed {
+	points := int(options[0].IntValue())
+	oldThreshold, err := pc.thresholdValue(i.GuildID, points)
+	if err != nil {
+		return utils.CreateErrorEmbed(s, i, "Error retrieving thresholds", err)
+	}
+	err = pc.cm.RemoveThreshold(i.GuildID, points)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return utils.CreateNotAllowedEmbed("No threshold found", fmt.Sprintf("There is no threshold at %d point(s).", points))
+		}
+		return utils.CreateErrorEmbed(s, i, "Error removing threshold", err)
+	}
+	pc.audit(s, i, fmt.Sprintf("Threshold at %d points", points), oldThreshold, "")
+	return utils.CreateEmbed("Threshold Removed", fmt.Sprintf("The threshold at %d point(s) has been removed.", points))
+}
+
+func (pc *PermissionCommands) handleViewPoints(s *discordgo.Session, i *discordgo.InteractionCreate) *discordgo.MessageEmbed {
+	weights, err := pc.cm.GetAllActionPoints(i.GuildID)
+	if err != nil {
+		return utils.CreateErrorEmbed(s, i, "Error retrieving points", err)
+	}
+	thresholds, err := pc.cm.GetThresholds(i.GuildID)
+	if err != nil {
+		return utils.CreateErrorEmbed(s, i, "Error retrieving thresholds", err)
+	}
+
+	actions := make([]string, 0, len(weights))
+	for action := range weights {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+	var weightLines strings.Builder
+	for _, action := range actions {
+		weightLines.WriteString(fmt.Sprintf("**%s**: %d\n", action, weights[action]))
+	}
+
+	var thresholdLines strings.Builder
+	if len(thresholds) == 0 {
+		thresholdLines.WriteString("No thresholds set.")
+	}
+	for _, t := range thresholds {
+		thresholdLines.WriteString(fmt.Sprintf("**%d points**: %s\n", t.Points, formatThreshold(t)))
+	}
+
+	decay := "Never"
+	if days, err := pc.pm.GetPointDecayDays(i.GuildID); err == nil && days > 0 {
+		decay = fmt.Sprintf("After %d day(s)", days)
+	}
+
+	embed := utils.CreateEmbed("Warning Points", "")
+	embed.Fields = []*discordgo.MessageEmbedField{
+		{Name: "Points per action", Value: weightLines.String(), Inline: true},
+		{Name: "Thresholds", Value: thresholdLines.String(), Inline: true},
+		{Name: "Points expire", Value: decay, Inline: false},
+	}
+	return embed
+}
+
+func (pc *PermissionCommands) handleSetPointDecay(s *discordgo.Session, i *discordgo.InteractionCreate, options []*discordgo.ApplicationCommandInteractionDataOption) *discordgo.MessageEmbed {
+	days := int(options[0].IntValue())
+	oldDecay := "Never"
+	if oldDays, err := pc.pm.GetPointDecayDays(i.GuildID); err == nil && oldDays > 0 {
+		oldDecay = fmt.Sprintf("%d day(s)", oldDays)
+	}
+	err := pc.pm.SetPointDecay(i.GuildID, days)
+	if err != nil {
+		return utils.CreateErrorEmbed(s, i, "Error setting point decay", err)
+	}
+	newDecay := "Never"
+	if days > 0 {
+		newDecay = fmt.Sprintf("%d day(s)", days)
+	}
+	pc.audit(s, i, "Point decay", oldDecay, newDecay)
+	if days == 0 {
+		return utils.CreateEmbed("Point Decay Set", "Points will never expire.")
+	}
+	return utils.CreateEmbed("Point Decay Set", fmt.Sprintf("Points will stop counting %d day(s) after the case was logged.", days))
+}
+
+// thresholdValue returns the threshold at a number of points for the audit log, empty if there is none
+func (pc *PermissionCommands) thresholdValue(guildID string, points int) (string, error) {
+	thresholds, err := pc.cm.GetThresholds(guildID)
+	if err != nil {
+		return "", err
+	}
+	for _, t := range thresholds {
+		if t.Points == points {
+			return formatThreshold(t), nil
+		}
+	}
+	return "", nil
+}
+
+func formatThreshold(t cases.Threshold) string {
+	if t.Duration > 0 {
+		return fmt.Sprintf("%s for %s", t.Action, utils.FormatDuration(t.Duration))
+	}
+	return t.Action
+}
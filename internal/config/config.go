@@ -6,6 +6,10 @@ import "github.com/spf13/viper"
 type Config struct {
 	Token        string
 	DatabasePath string
+
+	// Recent messages kept to log edits and deletes
+	MessageCacheSize    int
+	MessageCachePersist bool // Also save them to the database, so they survive restarts
 }
 
 func Load() (*Config, error) {
@@ -21,5 +25,8 @@ func Load() (*Config, error) {
 	return &Config{
 		Token:        viper.GetString("token"),
 		DatabasePath: viper.GetString("database_path"),
+
+		MessageCacheSize:    viper.GetInt("message_cache_size"),
+		MessageCachePersist: viper.GetBool("message_cache_persist"),
 	}, nil
 }
@@ -41,5 +41,34 @@ func New(dbPath string) (*sql.DB, error) {
 		return nil, err
 	}
 
+	// Pending isolation appeals, at most one per user per guild
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS appeals (
+			user_id TEXT,
+			guild_id TEXT,
+			channel_id TEXT,
+			message_id TEXT,
+			created_at INTEGER,
+			PRIMARY KEY (user_id, guild_id)
+		)
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	// Modmail conversations, each is a thread in the guild's modmail channel
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS modmail_threads (
+			thread_id TEXT PRIMARY KEY,
+			user_id TEXT,
+			guild_id TEXT,
+			opened_at INTEGER,
+			closed INTEGER DEFAULT 0
+		)
+	`)
+	if err != nil {
+		return nil, err
+	}
+
 	return db, nil
 }
@@ -0,0 +1,151 @@
+// internal/export/export.go
+package export
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shininglegend/shieldbot/internal/cases"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// Isolation is a user who is currently isolated, with the roles they'll get back
+type Isolation struct {
+	GuildID    string   `json:"guild_id"`
+	UserID     string   `json:"user_id"`
+	SavedRoles []string `json:"saved_roles"`
+}
+
+// Case is a mod case as it's exported
+type Case struct {
+	GuildID     string   `json:"guild_id"`
+	Number      int      `json:"case_number"`
+	CreatedAt   string   `json:"created_at"`
+	ModeratorID string   `json:"moderator_id"`
+	TargetID    string   `json:"target_id"`
+	Action      string   `json:"action"`
+	Reason      string   `json:"reason"`
+	Rule        int      `json:"rule,omitempty"`
+	Details     string   `json:"details"`
+	Points      int      `json:"points"`
+	LinkedCase  int      `json:"linked_case,omitempty"`
+	LogMessage  string   `json:"log_message,omitempty"`
+	Evidence    []string `json:"evidence,omitempty"`
+}
+
+// Export is everything exported for a filter
+type Export struct {
+	Cases      []Case      `json:"cases"`
+	Isolations []Isolation `json:"isolations"`
+}
+
+// Load reads the cases matching the filter and the isolations of the filtered guild and user.
+// Isolations aren't dated, so the date and action filters don't apply to them.
+func Load(db *sql.DB, cm *cases.CaseManager, f cases.Filter) (*Export, error) {
+	found, err := cm.SearchCases(f)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching cases: %w", err)
+	}
+	e := &Export{Cases: make([]Case, 0, len(found)), Isolations: []Isolation{}}
+	for _, c := range found {
+		attachments, err := cm.GetAttachments(c.GuildID, c.Number)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching attachments: %w", err)
+		}
+		exported := Case{
+			GuildID:     c.GuildID,
+			Number:      c.Number,
+			CreatedAt:   c.CreatedAt.UTC().Format(time.RFC3339),
+			ModeratorID: c.ModeratorID,
+			TargetID:    c.TargetID,
+			Action:      c.Action,
+			Reason:      c.Reason,
+			Rule:        c.Rule,
+			Details:     c.Details,
+			Points:      c.Points,
+			LinkedCase:  c.LinkedCase,
+		}
+		if c.LogMessageID != "" {
+			exported.LogMessage = fmt.Sprintf("https://discord.com/channels/%v/%v/%v", c.GuildID, c.LogChannelID, c.LogMessageID)
+		}
+		for _, a := range attachments {
+			exported.Evidence = append(exported.Evidence, a.URL)
+		}
+		e.Cases = append(e.Cases, exported)
+	}
+
+	query := "SELECT guild_id, user_id, roles FROM user_roles WHERE (? = '' OR guild_id = ?) AND (? = '' OR user_id = ?) ORDER BY guild_id, user_id"
+	rows, err := db.Query(query, f.GuildID, f.GuildID, f.UserID, f.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching isolations: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var isolation Isolation
+		var roles string
+		if err := rows.Scan(&isolation.GuildID, &isolation.UserID, &roles); err != nil {
+			return nil, fmt.Errorf("error fetching isolations: %w", err)
+		}
+		isolation.SavedRoles = []string{}
+		if roles != "" {
+			isolation.SavedRoles = strings.Split(roles, ",")
+		}
+		e.Isolations = append(e.Isolations, isolation)
+	}
+	return e, rows.Err()
+}
+
+func (e *Export) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(e)
+}
+
+func (e *Export) WriteCasesCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"guild_id", "case_number", "created_at", "moderator_id", "target_id", "action", "reason", "rule", "details", "points", "linked_case", "log_message", "evidence"})
+	for _, c := range e.Cases {
+		writer.Write([]string{
+			c.GuildID,
+			strconv.Itoa(c.Number),
+			c.CreatedAt,
+			c.ModeratorID,
+			c.TargetID,
+			c.Action,
+			c.Reason,
+			strconv.Itoa(c.Rule),
+			c.Details,
+			strconv.Itoa(c.Points),
+			strconv.Itoa(c.LinkedCase),
+			c.LogMessage,
+			strings.Join(c.Evidence, " "),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func (e *Export) WriteIsolationsCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"guild_id", "user_id", "saved_roles"})
+	for _, isolation := range e.Isolations {
+		writer.Write([]string{isolation.GuildID, isolation.UserID, strings.Join(isolation.SavedRoles, " ")})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// ParseDate reads a YYYY-MM-DD date in UTC, as used by the date filters
+func ParseDate(input string) (time.Time, error) {
+	return time.Parse("2006-01-02", strings.TrimSpace(input))
+}
@@ -1 +1,84 @@
+// internal/logging/logging.go
 package logging
+
+import (
+	"database/sql"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/shininglegend/shieldbot/internal/permissions"
+)
+
+// Category is a kind of log message, each can go to its own channel
+type Category string
+
+const (
+	// The main log channel, used by every category without its own
+	CategoryMain       Category = "main"
+	CategoryModeration Category = "moderation"
+	CategoryMembers    Category = "members"
+	CategoryMessages   Category = "messages"
+	CategoryConfig     Category = "config"
+	CategoryAutomod    Category = "automod"
+	CategoryErrors     Category = "errors"
+)
+
+// Categories lists every category with a description, in the order they're shown
+var Categories = []struct {
+	Category Category
+	Label    string
+}{
+	{CategoryMain, "Main (everything without its own channel)"},
+	{CategoryModeration, "Moderation actions"},
+	{CategoryMembers, "Member joins and leaves"},
+	{CategoryMessages, "Message edits and deletes"},
+	{CategoryConfig, "Config changes"},
+	{CategoryAutomod, "AutoMod hits"},
+	{CategoryErrors, "Bot errors"},
+}
+
+// CategoryLabel returns the description of a category, or false if it doesn't exist
+func CategoryLabel(category Category) (string, bool) {
+	for _, c := range Categories {
+		if c.Category == category {
+			return c.Label, true
+		}
+	}
+	return "", false
+}
+
+// Logger sends every log message, picking the channel for its category
+type Logger struct {
+	pm *permissions.PermissionManager
+}
+
+func New(pm *permissions.PermissionManager) *Logger {
+	return &Logger{pm: pm}
+}
+
+// ChannelID returns the channel for a category, falling back to the main log channel.
+// Returns sql.ErrNoRows if neither is set.
+func (l *Logger) ChannelID(guildID string, category Category) (string, error) {
+	if category != CategoryMain {
+		channelID, err := l.pm.GetCategoryLogChannelID(guildID, string(category))
+		if err != sql.ErrNoRows {
+			return channelID, err
+		}
+	}
+	return l.pm.GetLogChannelID(guildID)
+}
+
+// Send posts a message to the channel for its category.
+// Returns sql.ErrNoRows if the guild has nowhere to log it.
+func (l *Logger) Send(s *discordgo.Session, guildID string, category Category, msg *discordgo.MessageSend) (*discordgo.Message, error) {
+	channelID, err := l.ChannelID(guildID, category)
+	if err != nil {
+		return nil, err
+	}
+	return s.ChannelMessageSendComplex(channelID, msg)
+}
+
+// SendEmbed posts a single embed to the channel for its category
+func (l *Logger) SendEmbed(s *discordgo.Session, guildID string, category Category, embed *discordgo.MessageEmbed) error {
+	_, err := l.Send(s, guildID, category, &discordgo.MessageSend{Embed: embed})
+	return err
+}
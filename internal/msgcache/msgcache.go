@@ -0,0 +1,173 @@
+// internal/msgcache/msgcache.go
+package msgcache
+
+import (
+	"container/list"
+	"database/sql"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Used when the configured size isn't positive
+	DefaultSize = 5000
+	// The database is trimmed back to size once every this many messages
+	trimInterval = 100
+)
+
+// Message is what's kept of a message, so it can still be shown after it's edited or deleted
+type Message struct {
+	ID          string
+	GuildID     string
+	ChannelID   string
+	AuthorID    string
+	AuthorName  string
+	Content     string
+	Attachments []string // URLs
+	CreatedAt   time.Time
+}
+
+// Cache holds the most recent messages, dropping the oldest once it's full.
+// With a database it's also saved there, so it survives restarts.
+type Cache struct {
+	mu       sync.Mutex
+	db       *sql.DB // nil to keep messages in memory only
+	size     int
+	added    int                      // Messages saved since the database was last trimmed
+	order    *list.List               // Oldest first
+	messages map[string]*list.Element // By message ID, values are *Message
+}
+
+// NewCache creates a cache of up to size messages. db may be nil.
+func NewCache(db *sql.DB, size int) *Cache {
+	if size <= 0 {
+		size = DefaultSize
+	}
+	return &Cache{
+		db:       db,
+		size:     size,
+		order:    list.New(),
+		messages: make(map[string]*list.Element),
+	}
+}
+
+func (c *Cache) SetupTables() error {
+	if c.db == nil {
+		return nil
+	}
+	queries := []string{
+		`CREATE TABLE IF NOT EXISTS message_cache (
+			message_id TEXT PRIMARY KEY,
+			guild_id TEXT,
+			channel_id TEXT,
+			author_id TEXT,
+			author_name TEXT,
+			content TEXT,
+			attachments TEXT,
+			created_at INTEGER
+		)`,
+		`CREATE INDEX IF NOT EXISTS message_cache_created ON message_cache (created_at)`,
+	}
+	for _, query := range queries {
+		_, err := c.db.Exec(query)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Add stores a message, or replaces it if it's already cached
+func (c *Cache) Add(m *Message) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.messages[m.ID]; ok {
+		e.Value = m
+	} else {
+		c.messages[m.ID] = c.order.PushBack(m)
+		for c.order.Len() > c.size {
+			oldest := c.order.Remove(c.order.Front()).(*Message)
+			delete(c.messages, oldest.ID)
+		}
+	}
+
+	if c.db == nil {
+		return nil
+	}
+	_, err := c.db.Exec(`
+		INSERT INTO message_cache (message_id, guild_id, channel_id, author_id, author_name, content, attachments, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(message_id) DO UPDATE SET content = excluded.content, attachments = excluded.attachments`,
+		m.ID, m.GuildID, m.ChannelID, m.AuthorID, m.AuthorName, m.Content, strings.Join(m.Attachments, "\n"), m.CreatedAt.Unix())
+	if err != nil {
+		return err
+	}
+
+	// Keep the table about the same size as the cache
+	c.added++
+	if c.added < trimInterval {
+		return nil
+	}
+	c.added = 0
+	_, err = c.db.Exec(`
+		DELETE FROM message_cache WHERE rowid NOT IN (
+			SELECT rowid FROM message_cache ORDER BY created_at DESC LIMIT ?
+		)`, c.size)
+	return err
+}
+
+// Get returns a cached message, or nil if it isn't cached
+func (c *Cache) Get(messageID string) (*Message, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.messages[messageID]; ok {
+		return e.Value.(*Message), nil
+	}
+	if c.db == nil {
+		return nil, nil
+	}
+
+	// Messages from before a restart are only in the database
+	m := &Message{}
+	var attachments string
+	var createdAt int64
+	err := c.db.QueryRow(`
+		SELECT message_id, guild_id, channel_id, author_id, author_name, content, attachments, created_at
+		FROM message_cache WHERE message_id = ?`, messageID).
+		Scan(&m.ID, &m.GuildID, &m.ChannelID, &m.AuthorID, &m.AuthorName, &m.Content, &attachments, &createdAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if attachments != "" {
+		m.Attachments = strings.Split(attachments, "\n")
+	}
+	m.CreatedAt = time.Unix(createdAt, 0)
+	return m, nil
+}
+
+// Remove forgets messages, so their deletion isn't logged
+func (c *Cache) Remove(messageIDs ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, id := range messageIDs {
+		if e, ok := c.messages[id]; ok {
+			c.order.Remove(e)
+			delete(c.messages, id)
+		}
+		if c.db == nil {
+			continue
+		}
+		_, err := c.db.Exec("DELETE FROM message_cache WHERE message_id = ?", id)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
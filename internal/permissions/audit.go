@@ -0,0 +1,59 @@
+// internal/permissions/audit.go
+package permissions
+
+import "time"
+
+// AuditEntry is a single change to a guild's configuration
+type AuditEntry struct {
+	ID        int64
+	GuildID   string
+	UserID    string // Who made the change
+	Setting   string
+	OldValue  string // Empty if it wasn't set
+	NewValue  string // Empty if it was removed
+	CreatedAt time.Time
+}
+
+// AddAuditEntry records a configuration change, setting its ID
+func (pm *PermissionManager) AddAuditEntry(e *AuditEntry) error {
+	result, err := pm.db.Exec(`
+		INSERT INTO config_audit (guild_id, user_id, setting, old_value, new_value, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		e.GuildID, e.UserID, e.Setting, e.OldValue, e.NewValue, e.CreatedAt.Unix())
+	if err != nil {
+		return err
+	}
+	e.ID, err = result.LastInsertId()
+	return err
+}
+
+// GetAuditLog returns a guild's configuration changes, newest first
+func (pm *PermissionManager) GetAuditLog(guildID string, limit, offset int) ([]AuditEntry, error) {
+	rows, err := pm.db.Query(`
+		SELECT id, guild_id, user_id, setting, old_value, new_value, created_at
+		FROM config_audit WHERE guild_id = ? ORDER BY id DESC LIMIT ? OFFSET ?`,
+		guildID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []AuditEntry
+	for rows.Next() {
+		var e AuditEntry
+		var createdAt int64
+		if err := rows.Scan(&e.ID, &e.GuildID, &e.UserID, &e.Setting, &e.OldValue, &e.NewValue, &createdAt); err != nil {
+			return nil, err
+		}
+		e.CreatedAt = time.Unix(createdAt, 0)
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// CountAuditLog returns how many configuration changes a guild has
+func (pm *PermissionManager) CountAuditLog(guildID string) (int, error) {
+	var count int
+	err := pm.db.QueryRow("SELECT COUNT(*) FROM config_audit WHERE guild_id = ?", guildID).Scan(&count)
+	return count, err
+}
@@ -4,6 +4,7 @@ package permissions
 
 import (
 	"database/sql"
+	"strconv"
 	"sync"
 
 	"github.com/bwmarrin/discordgo"
@@ -245,6 +246,106 @@ func (pm *PermissionManager) GetLogChannelID(guildID string) (string, error) {
 	return channelID, nil
 }
 
+// SetCategoryLogChannel routes one category of log messages to its own channel
+func (pm *PermissionManager) SetCategoryLogChannel(guildID, category, channelID string) error {
+	return pm.setSetting(guildID, "log_channel_"+category, channelID)
+}
+
+// GetCategoryLogChannelID returns the channel of a log category, or sql.ErrNoRows if it uses the main one
+func (pm *PermissionManager) GetCategoryLogChannelID(guildID, category string) (string, error) {
+	return pm.getSetting(guildID, "log_channel_"+category)
+}
+
+func (pm *PermissionManager) SetAppealMessage(guildID, message string) error {
+	return pm.setSetting(guildID, "appeal_message", message)
+}
+
+// GetAppealMessage returns the appeal message template, or sql.ErrNoRows if none is set
+func (pm *PermissionManager) GetAppealMessage(guildID string) (string, error) {
+	return pm.getSetting(guildID, "appeal_message")
+}
+
+func (pm *PermissionManager) SetAppealsChannel(guildID, channelID string) error {
+	return pm.setSetting(guildID, "appeals_channel", channelID)
+}
+
+func (pm *PermissionManager) GetAppealsChannelID(guildID string) (string, error) {
+	return pm.getSetting(guildID, "appeals_channel")
+}
+
+func (pm *PermissionManager) SetModmailChannel(guildID, channelID string) error {
+	return pm.setSetting(guildID, "modmail_channel", channelID)
+}
+
+func (pm *PermissionManager) GetModmailChannelID(guildID string) (string, error) {
+	return pm.getSetting(guildID, "modmail_channel")
+}
+
+// SetCaseThreads sets whether a discussion thread is opened on each mod log entry, and after how many minutes it's archived
+func (pm *PermissionManager) SetCaseThreads(guildID string, enabled bool, archiveMinutes int) error {
+	err := pm.setSetting(guildID, "case_threads", strconv.FormatBool(enabled))
+	if err != nil {
+		return err
+	}
+	return pm.setSetting(guildID, "case_thread_archive", strconv.Itoa(archiveMinutes))
+}
+
+// GetCaseThreads returns whether case threads are enabled and their auto archive duration in minutes.
+// Threads are disabled if it isn't set.
+func (pm *PermissionManager) GetCaseThreads(guildID string) (bool, int, error) {
+	value, err := pm.getSetting(guildID, "case_threads")
+	if err == sql.ErrNoRows {
+		return false, 0, nil
+	}
+	if err != nil {
+		return false, 0, err
+	}
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, 0, err
+	}
+	value, err = pm.getSetting(guildID, "case_thread_archive")
+	if err != nil {
+		return false, 0, err
+	}
+	archiveMinutes, err := strconv.Atoi(value)
+	return enabled, archiveMinutes, err
+}
+
+// SetPointDecay sets how many days cases count towards a member's points. 0 means they never expire.
+func (pm *PermissionManager) SetPointDecay(guildID string, days int) error {
+	return pm.setSetting(guildID, "point_decay_days", strconv.Itoa(days))
+}
+
+// GetPointDecayDays returns how many days cases count towards a member's points, or sql.ErrNoRows if it isn't set
+func (pm *PermissionManager) GetPointDecayDays(guildID string) (int, error) {
+	value, err := pm.getSetting(guildID, "point_decay_days")
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(value)
+}
+
+// setSetting stores a single value for a guild setting, replacing any previous value
+func (pm *PermissionManager) setSetting(guildID, name, value string) error {
+	_, err := pm.db.Exec(`
+		INSERT INTO guild_settings (guild_id, setting_name, role_id)
+		VALUES (?, ?, ?)
+		ON CONFLICT(guild_id, setting_name) DO UPDATE SET role_id = ?`,
+		guildID, name, value, value)
+	return err
+}
+
+// getSetting fetches a single guild setting, returning sql.ErrNoRows if it isn't set
+func (pm *PermissionManager) getSetting(guildID, name string) (string, error) {
+	var value string
+	err := pm.db.QueryRow("SELECT role_id FROM guild_settings WHERE guild_id = ? AND setting_name = ?", guildID, name).Scan(&value)
+	if err != nil {
+		return "", err
+	}
+	return value, nil
+}
+
 func (pm *PermissionManager) SetupTables() error {
 	queries := []string{
 		// In the future, role_id should be called setting_id instead. Do not expect it to be a role ID.
@@ -260,6 +361,19 @@ func (pm *PermissionManager) SetupTables() error {
 			role_id TEXT, 
 			PRIMARY KEY (guild_id, setting_name)
 		)`,
+		`CREATE TABLE IF NOT EXISTS config_audit (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			guild_id TEXT,
+			user_id TEXT,
+			setting TEXT,
+			old_value TEXT,
+			new_value TEXT,
+			created_at INTEGER
+		)`,
+		`CREATE INDEX IF NOT EXISTS config_audit_guild ON config_audit (guild_id, id)`,
+		`CREATE TABLE IF NOT EXISTS permission_migrations (
+			name TEXT PRIMARY KEY
+		)`,
 	}
 
 	for _, query := range queries {
@@ -269,5 +383,47 @@ func (pm *PermissionManager) SetupTables() error {
 		}
 	}
 
+	err := pm.migrateSharedOverrides()
+	if err != nil {
+		return err
+	}
 	return pm.loadPermissions()
 }
+
+// sharedOverrides are the overrides that used to cover other commands, and the commands they covered.
+// Every Manage Roles command checked the isolate override and every Manage Messages one the purge override,
+// before each command got its own.
+var sharedOverrides = map[string][]string{
+	"isolate": {"restore"},
+	"purge":   {"log", "elog", "note", "case", "history", "whois", "reply", "close"},
+}
+
+// migrateSharedOverrides gives the roles of a shared override the overrides of the commands it covered, so they keep working.
+// It only runs once, so overrides removed afterwards stay removed.
+func (pm *PermissionManager) migrateSharedOverrides() error {
+	tx, err := pm.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("INSERT OR IGNORE INTO permission_migrations (name) VALUES ('shared_overrides')")
+	if err != nil {
+		return err
+	}
+	if rows, err := result.RowsAffected(); err != nil || rows == 0 {
+		return err
+	}
+	for shared, commands := range sharedOverrides {
+		for _, command := range commands {
+			_, err := tx.Exec(`
+				INSERT OR IGNORE INTO command_permissions (guild_id, command_name, role_id)
+				SELECT guild_id, ?, role_id FROM command_permissions WHERE command_name = ?`,
+				command, shared)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
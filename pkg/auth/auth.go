@@ -18,28 +18,13 @@ const (
 // Check if a user has the manage roles permission or has an override for this command.
 // Uses cache if possible.
 func QuickAuthManageRolesOrOverride(pm *permissions.PermissionManager, s *discordgo.Session, i *discordgo.InteractionCreate) *discordgo.MessageEmbed {
-	// Sanity check
-	if i.Member == nil {
-		return utils.CreateNotAllowedEmbed(ErrServerOnly, "What, you think I live here too?")
-	}
+	return QuickAuthManageRolesOrOverrideFor(pm, s, i, commandName(i))
+}
 
-	// Ensure the member has manage roles permission
-	permissions, err := s.UserChannelPermissions(i.Member.User.ID, i.ChannelID)
-	if err != nil {
-		log.Printf("Error fetching permissions: %v", err)
-		return utils.CreateErrorEmbed(s, i, ErrorGeneric, err)
-	}
-	if permissions&discordgo.PermissionManageRoles != discordgo.PermissionManageRoles {
-		allowed, err := pm.CanUseCommand(s, i.GuildID, i.Member.User.ID, "isolate")
-		if err != nil {
-			log.Printf("Error checking permissions: %v", err)
-			return utils.CreateErrorEmbed(s, i, ErrorGeneric, err)
-		}
-		if !allowed {
-			return AddMissingPerms(utils.CreateNotAllowedEmbed(ErrorNoPerms, ""), []string{"Manage Roles"})
-		}
-	}
-	return nil
+// QuickAuthManageRolesOrOverrideFor is QuickAuthManageRolesOrOverride with the override of the given command,
+// for buttons, modals and actions taken on behalf of another command.
+func QuickAuthManageRolesOrOverrideFor(pm *permissions.PermissionManager, s *discordgo.Session, i *discordgo.InteractionCreate, command string) *discordgo.MessageEmbed {
+	return quickAuthPermissionOrOverride(pm, s, i, discordgo.PermissionManageRoles, "Manage Roles", command)
 }
 
 // Check if a user has the admin permission or has an override for this command.
@@ -70,24 +55,79 @@ func QuickAuthAdminOrOverride(pm *permissions.PermissionManager, s *discordgo.Se
 // Check if a user has the manage messages permission or has an override for this command.
 // Uses cache if possible.
 func QuickAuthManageMessagesOrOverride(pm *permissions.PermissionManager, s *discordgo.Session, i *discordgo.InteractionCreate) *discordgo.MessageEmbed {
+	return QuickAuthManageMessagesOrOverrideFor(pm, s, i, commandName(i))
+}
+
+// QuickAuthManageMessagesOrOverrideFor is QuickAuthManageMessagesOrOverride with the override of the given command
+func QuickAuthManageMessagesOrOverrideFor(pm *permissions.PermissionManager, s *discordgo.Session, i *discordgo.InteractionCreate, command string) *discordgo.MessageEmbed {
+	return quickAuthPermissionOrOverride(pm, s, i, discordgo.PermissionManageMessages, "Manage Messages", command)
+}
+
+// Check if a user has the moderate members permission or has an override for this command.
+// Uses cache if possible.
+func QuickAuthModerateMembersOrOverride(pm *permissions.PermissionManager, s *discordgo.Session, i *discordgo.InteractionCreate) *discordgo.MessageEmbed {
+	return QuickAuthModerateMembersOrOverrideFor(pm, s, i, commandName(i))
+}
+
+// QuickAuthModerateMembersOrOverrideFor is QuickAuthModerateMembersOrOverride with the override of the given command
+func QuickAuthModerateMembersOrOverrideFor(pm *permissions.PermissionManager, s *discordgo.Session, i *discordgo.InteractionCreate, command string) *discordgo.MessageEmbed {
+	return quickAuthPermissionOrOverride(pm, s, i, discordgo.PermissionModerateMembers, "Timeout Members", command)
+}
+
+// Check if a user has the kick members permission or has an override for this command.
+// Uses cache if possible.
+func QuickAuthKickMembersOrOverride(pm *permissions.PermissionManager, s *discordgo.Session, i *discordgo.InteractionCreate) *discordgo.MessageEmbed {
+	return QuickAuthKickMembersOrOverrideFor(pm, s, i, commandName(i))
+}
+
+// QuickAuthKickMembersOrOverrideFor is QuickAuthKickMembersOrOverride with the override of the given command
+func QuickAuthKickMembersOrOverrideFor(pm *permissions.PermissionManager, s *discordgo.Session, i *discordgo.InteractionCreate, command string) *discordgo.MessageEmbed {
+	return quickAuthPermissionOrOverride(pm, s, i, discordgo.PermissionKickMembers, "Kick Members", command)
+}
+
+// Check if a user has the ban members permission or has an override for this command.
+// Uses cache if possible.
+func QuickAuthBanMembersOrOverride(pm *permissions.PermissionManager, s *discordgo.Session, i *discordgo.InteractionCreate) *discordgo.MessageEmbed {
+	return QuickAuthBanMembersOrOverrideFor(pm, s, i, commandName(i))
+}
+
+// QuickAuthBanMembersOrOverrideFor is QuickAuthBanMembersOrOverride with the override of the given command
+func QuickAuthBanMembersOrOverrideFor(pm *permissions.PermissionManager, s *discordgo.Session, i *discordgo.InteractionCreate, command string) *discordgo.MessageEmbed {
+	return quickAuthPermissionOrOverride(pm, s, i, discordgo.PermissionBanMembers, "Ban Members", command)
+}
+
+// commandName returns the command an interaction invoked, whose overrides apply.
+// Buttons and modals don't carry one, so they only pass with the permission itself unless their handler names the command.
+func commandName(i *discordgo.InteractionCreate) string {
+	switch i.Type {
+	case discordgo.InteractionApplicationCommand, discordgo.InteractionApplicationCommandAutocomplete:
+		return i.ApplicationCommandData().Name
+	}
+	return ""
+}
+
+// Check if a user has a permission in the channel, or a role override for the given command
+func quickAuthPermissionOrOverride(pm *permissions.PermissionManager, s *discordgo.Session, i *discordgo.InteractionCreate, permission int64, permissionName, command string) *discordgo.MessageEmbed {
 	// Sanity check
 	if i.Member == nil {
 		return utils.CreateNotAllowedEmbed(ErrServerOnly, "What, you think I live here too?")
 	}
-	// Ensure the member has manage messages permission
 	permissions, err := s.UserChannelPermissions(i.Member.User.ID, i.ChannelID)
 	if err != nil {
 		log.Printf("Error fetching permissions: %v", err)
 		return utils.CreateErrorEmbed(s, i, "Failed to fetch permissions", err)
 	}
-	if permissions&discordgo.PermissionManageMessages != discordgo.PermissionManageMessages {
-		allowed, err := pm.CanUseCommand(s, i.GuildID, i.Member.User.ID, "purge")
+	if permissions&permission != permission {
+		if command == "" {
+			return AddMissingPerms(utils.CreateNotAllowedEmbed(ErrorNoPerms, ""), []string{permissionName})
+		}
+		allowed, err := pm.CanUseCommand(s, i.GuildID, i.Member.User.ID, command)
 		if err != nil {
 			log.Printf("Error checking permissions: %v", err)
 			return utils.CreateErrorEmbed(s, i, ErrorGeneric, err)
 		}
 		if !allowed {
-			return AddMissingPerms(utils.CreateNotAllowedEmbed(ErrorNoPerms, ""), []string{"Manage Messages"})
+			return AddMissingPerms(utils.CreateNotAllowedEmbed(ErrorNoPerms, ""), []string{permissionName})
 		}
 	}
 	return nil
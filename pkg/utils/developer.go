@@ -15,6 +15,10 @@ const (
 
 var DevChannel = (*discordgo.Channel)(nil)
 
+// OnError is called with every error reported through CreateErrorEmbed, if set.
+// The bot uses it to post errors to the guild's own log channel too.
+var OnError func(s *discordgo.Session, i *discordgo.InteractionCreate, desc string, err error)
+
 // This does it's best to deliver messages!
 func SendToDevChannelDMs(s *discordgo.Session, msg string, retries int) {
 	_, err := s.ChannelMessageSend(GetDevChannel(s), msg)
@@ -2,7 +2,9 @@ package utils
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
 )
@@ -44,6 +46,16 @@ func CheckError(err error, checkCode int) bool {
 	return ok && e.Message != nil && e.Message.Code == checkCode
 }
 
+// SendDM sends an embed to a user in their DMs
+func SendDM(s *discordgo.Session, userID string, embed *discordgo.MessageEmbed) error {
+	channel, err := s.UserChannelCreate(userID)
+	if err != nil {
+		return err
+	}
+	_, err = s.ChannelMessageSendEmbed(channel.ID, embed)
+	return err
+}
+
 // CreateEmbed creates a simple embed with a title and description
 func CreateEmbed(title, description string) *discordgo.MessageEmbed {
 	return &discordgo.MessageEmbed{
@@ -57,6 +69,9 @@ func CreateEmbed(title, description string) *discordgo.MessageEmbed {
 func CreateErrorEmbed(s *discordgo.Session, i *discordgo.InteractionCreate, desc string, err error) *discordgo.MessageEmbed {
 	// Send a message to the developer with a link to the message, the user, and the error
 	SendToDevChannelDMs(s, fmt.Sprintf("Error: `%v`\nUser: %v | Channel: %v", err, SafeUser(i.Interaction).ID, i.ChannelID), 2)
+	if OnError != nil {
+		OnError(s, i, desc, err)
+	}
 	return &discordgo.MessageEmbed{
 		Title:       "Error",
 		Description: desc,
@@ -112,3 +127,58 @@ func GetHighestRole(memberRoles []string, guildRoles []*discordgo.Role) *discord
 	}
 	return highestRole
 }
+
+// FindOption returns the option with the given name, or nil if it wasn't provided
+func FindOption(options []*discordgo.ApplicationCommandInteractionDataOption, name string) *discordgo.ApplicationCommandInteractionDataOption {
+	for _, option := range options {
+		if option.Name == name {
+			return option
+		}
+	}
+	return nil
+}
+
+// ParseDuration parses durations like "30m", "12h", "7d" or "2w".
+// Anything Go's time.ParseDuration understands is accepted as well.
+func ParseDuration(input string) (time.Duration, error) {
+	input = strings.TrimSpace(strings.ToLower(input))
+	if input == "" {
+		return 0, fmt.Errorf("empty duration")
+	}
+	unit := input[len(input)-1]
+	if unit == 'd' || unit == 'w' {
+		n, err := strconv.Atoi(input[:len(input)-1])
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid duration: %v", input)
+		}
+		d := time.Duration(n) * 24 * time.Hour
+		if unit == 'w' {
+			d *= 7
+		}
+		return d, nil
+	}
+	d, err := time.ParseDuration(input)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid duration: %v", input)
+	}
+	return d, nil
+}
+
+// FormatDuration returns a short human readable duration, like "2d 3h"
+func FormatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	days := int(d / (24 * time.Hour))
+	hours := int(d % (24 * time.Hour) / time.Hour)
+	minutes := int(d % time.Hour / time.Minute)
+	var parts []string
+	if days > 0 {
+		parts = append(parts, fmt.Sprintf("%dd", days))
+	}
+	if hours > 0 {
+		parts = append(parts, fmt.Sprintf("%dh", hours))
+	}
+	if minutes > 0 || len(parts) == 0 {
+		parts = append(parts, fmt.Sprintf("%dm", minutes))
+	}
+	return strings.Join(parts, " ")
+}